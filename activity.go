@@ -2,9 +2,7 @@ package withings
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -124,35 +122,25 @@ func (p *GetActivityParam) UpdateQuery(q url.Values) url.Values {
 func (c *Client) GetActivity(ctx context.Context, token AccessToken, param GetActivityParam) (*GetActivityResp, error) {
 
 	// Construct authorized request to request data from the API.
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, APIPathGetV2Measure, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.apiURL(PathMeasureV2), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build http request: %w", err)
 	}
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token.AccessToken))
+	req = authorize(req, token)
 
 	// Updating the query with the parameters generated by the param provided.
 	req.URL.RawQuery = param.UpdateQuery(req.URL.Query()).Encode()
 
 	// Executing the request.
-	resp, err := c.HttpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read body of request: %w", err)
-	}
-
 	var mResp GetActivityResp
-	if err = json.Unmarshal(body, &mResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	if err = c.do(req, &mResp); err != nil {
+		return nil, err
 	}
 
 	switch mResp.Status {
 	case 0:
 		return &mResp, nil
 	default:
-		return &mResp, fmt.Errorf("api returned an error: %s", mResp.APIError)
+		return &mResp, newAPIError(req, mResp.Status, mResp.APIError)
 	}
 }
@@ -0,0 +1,147 @@
+package withings
+
+import "context"
+
+// DataAPI provides the data of a single user. It is satisfied by the UserAPI of an AuthorizedUser and by the TokenAPI
+// of a Client, allowing code depending on it to be tested with a fake such as the one of the withingsfake package.
+type DataAPI interface {
+	GetMeasure(ctx context.Context, param GetMeasureParam) (*GetMeasureResp, error)
+	GetIntraDayActivity(ctx context.Context, param GetIntraDayActivityParam) (*GetIntraDayActivityResp, error)
+	GetIntraDayActivityChunked(ctx context.Context, param GetIntraDayActivityParam, opts ChunkOptions) (*GetIntraDayActivityResp, error)
+	GetActivity(ctx context.Context, param GetActivityParam) (*GetActivityResp, error)
+	GetHeartList(ctx context.Context, param GetHeartListParam) (*GetHeartResp, error)
+	GetHeartHighFrequencyData(ctx context.Context, param GetHeartHighFrequencyDataParam) (*GetHeartHighFrequencyDataResp, error)
+	GetStethoList(ctx context.Context, param GetStethoListParam) (*GetStethoListResp, error)
+	GetStethoSignal(ctx context.Context, param GetStethoSignalParam) (*GetStethoSignalResp, error)
+	GetSleep(ctx context.Context, param GetSleepParam) (*GetSleepResp, error)
+	GetSleepChunked(ctx context.Context, param GetSleepParam, opts ChunkOptions) (*GetSleepResp, error)
+	GetSleepSummary(ctx context.Context, param GetSleepSummaryParam) (*GetSleepSummaryResp, error)
+	GetWorkout(ctx context.Context, param GetWorkoutParam) (*GetWorkoutResp, error)
+	GetUserDevice(ctx context.Context) (*GetUserDeviceResp, error)
+	GetUserGoals(ctx context.Context) (*GetUserGoalsResp, error)
+}
+
+// NotifyAPI manages the notification subscriptions of a single user. It is satisfied by the UserAPI of an
+// AuthorizedUser and by the TokenAPI of a Client.
+type NotifyAPI interface {
+	SubscribeToNotifications(ctx context.Context, param SubscribeToNotificationsParam) (*SubscribeToNotificationsResp, error)
+	GetNotification(ctx context.Context, param GetNotificationParam) (*GetNotificationResp, error)
+	ListNotification(ctx context.Context, param ListNotificationParam) (*ListNotificationResp, error)
+	RevokeNotification(ctx context.Context, param RevokeNotificationParam) (*RevokeNotificationResp, error)
+	UpdateNotification(ctx context.Context, param UpdateNotificationParam) (*UpdateNotificationResp, error)
+}
+
+var (
+	_ DataAPI   = (*UserAPI)(nil)
+	_ NotifyAPI = (*UserAPI)(nil)
+	_ DataAPI   = (*TokenAPI)(nil)
+	_ NotifyAPI = (*TokenAPI)(nil)
+)
+
+// TokenAPI provides the methods of a client for a single user with the token of the user bound. Unlike the UserAPI of
+// an AuthorizedUser the token is never refreshed.
+type TokenAPI struct {
+	c     *Client
+	token AccessToken
+}
+
+// ForToken returns the methods of the client bound to the token provided.
+func (c *Client) ForToken(token AccessToken) *TokenAPI {
+	return &TokenAPI{c: c, token: token}
+}
+
+// GetMeasure returns the measures for the user based on the param provided.
+func (t *TokenAPI) GetMeasure(ctx context.Context, param GetMeasureParam) (*GetMeasureResp, error) {
+	return t.c.GetMeasure(ctx, t.token, param)
+}
+
+// GetIntraDayActivity returns the intra day activities for the user based on the param provided.
+func (t *TokenAPI) GetIntraDayActivity(ctx context.Context, param GetIntraDayActivityParam) (*GetIntraDayActivityResp, error) {
+	return t.c.GetIntraDayActivity(ctx, t.token, param)
+}
+
+// GetIntraDayActivityChunked returns the intra day activities for the user over a range longer than the API allows in
+// one request.
+func (t *TokenAPI) GetIntraDayActivityChunked(ctx context.Context, param GetIntraDayActivityParam, opts ChunkOptions) (*GetIntraDayActivityResp, error) {
+	return t.c.GetIntraDayActivityChunked(ctx, t.token, param, opts)
+}
+
+// GetActivity returns the activities for the user based on the param provided.
+func (t *TokenAPI) GetActivity(ctx context.Context, param GetActivityParam) (*GetActivityResp, error) {
+	return t.c.GetActivity(ctx, t.token, param)
+}
+
+// GetHeartList returns the heart data for the user based on the param provided.
+func (t *TokenAPI) GetHeartList(ctx context.Context, param GetHeartListParam) (*GetHeartResp, error) {
+	return t.c.GetHeartList(ctx, t.token, param)
+}
+
+// GetHeartHighFrequencyData returns the high frequency heart data for the user based on the param provided.
+func (t *TokenAPI) GetHeartHighFrequencyData(ctx context.Context, param GetHeartHighFrequencyDataParam) (*GetHeartHighFrequencyDataResp, error) {
+	return t.c.GetHeartHighFrequencyData(ctx, t.token, param)
+}
+
+// GetStethoList returns the stethoscope recordings for the user based on the param provided.
+func (t *TokenAPI) GetStethoList(ctx context.Context, param GetStethoListParam) (*GetStethoListResp, error) {
+	return t.c.GetStethoList(ctx, t.token, param)
+}
+
+// GetStethoSignal returns the audio signal of a stethoscope recording for the user based on the param provided.
+func (t *TokenAPI) GetStethoSignal(ctx context.Context, param GetStethoSignalParam) (*GetStethoSignalResp, error) {
+	return t.c.GetStethoSignal(ctx, t.token, param)
+}
+
+// GetSleep returns the sleep data for the user based on the param provided.
+func (t *TokenAPI) GetSleep(ctx context.Context, param GetSleepParam) (*GetSleepResp, error) {
+	return t.c.GetSleep(ctx, t.token, param)
+}
+
+// GetSleepChunked returns the sleep data for the user over a range longer than the API allows in one request.
+func (t *TokenAPI) GetSleepChunked(ctx context.Context, param GetSleepParam, opts ChunkOptions) (*GetSleepResp, error) {
+	return t.c.GetSleepChunked(ctx, t.token, param, opts)
+}
+
+// GetSleepSummary returns the sleep summaries for the user based on the param provided.
+func (t *TokenAPI) GetSleepSummary(ctx context.Context, param GetSleepSummaryParam) (*GetSleepSummaryResp, error) {
+	return t.c.GetSleepSummary(ctx, t.token, param)
+}
+
+// GetWorkout returns the workouts for the user based on the param provided.
+func (t *TokenAPI) GetWorkout(ctx context.Context, param GetWorkoutParam) (*GetWorkoutResp, error) {
+	return t.c.GetWorkout(ctx, t.token, param)
+}
+
+// GetUserDevice returns the devices linked to the user.
+func (t *TokenAPI) GetUserDevice(ctx context.Context) (*GetUserDeviceResp, error) {
+	return t.c.GetUserDevice(ctx, t.token)
+}
+
+// GetUserGoals returns the goals of the user.
+func (t *TokenAPI) GetUserGoals(ctx context.Context) (*GetUserGoalsResp, error) {
+	return t.c.GetUserGoals(ctx, t.token)
+}
+
+// SubscribeToNotifications subscribes the user to the notifications described by the param provided.
+func (t *TokenAPI) SubscribeToNotifications(ctx context.Context, param SubscribeToNotificationsParam) (*SubscribeToNotificationsResp, error) {
+	return t.c.SubscribeToNotifications(ctx, t.token, param)
+}
+
+// GetNotification returns the notification subscription of the user matching the param provided.
+func (t *TokenAPI) GetNotification(ctx context.Context, param GetNotificationParam) (*GetNotificationResp, error) {
+	return t.c.GetNotification(ctx, t.token, param)
+}
+
+// ListNotification lists the notification subscriptions of the user based on the param provided.
+func (t *TokenAPI) ListNotification(ctx context.Context, param ListNotificationParam) (*ListNotificationResp, error) {
+	return t.c.ListNotification(ctx, t.token, param)
+}
+
+// RevokeNotification revokes the notification subscription of the user matching the param provided.
+func (t *TokenAPI) RevokeNotification(ctx context.Context, param RevokeNotificationParam) (*RevokeNotificationResp, error) {
+	return t.c.RevokeNotification(ctx, t.token, param)
+}
+
+// UpdateNotification updates the notification subscription of the user based on the param provided.
+func (t *TokenAPI) UpdateNotification(ctx context.Context, param UpdateNotificationParam) (*UpdateNotificationResp, error) {
+	return t.c.UpdateNotification(ctx, t.token, param)
+}
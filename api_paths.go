@@ -1,12 +1,40 @@
 package withings
 
 const (
-	APIPathUserAuthorize   = "https://account.withings.com/oauth2_user/authorize2"
-	APIPathUserAccessToken = "https://wbsapi.withings.net/v2/oauth2"
-	APIPathGetMeas         = "https://wbsapi.withings.net/measure"
-	APIPathGetV2Measure    = "https://wbsapi.withings.net/v2/measure"
-	APIHeartV2             = "https://wbsapi.withings.net/v2/heart"
-	APISleepV2             = "https://wbsapi.withings.net/v2/sleep"
-	APINotify              = "https://wbsapi.withings.net/notify"
-	APIUser                = "https://wbsapi.withings.net/v2/user"
+	// DefaultAPIBaseURL is the base URL of the Withings data API. It is used unless the client is configured with
+	// WithAPIBaseURL.
+	DefaultAPIBaseURL = "https://wbsapi.withings.net"
+
+	// DefaultAccountBaseURL is the base URL of the Withings account service that hosts the OAuth 2.0 authorization
+	// page. It is used unless the client is configured with WithAccountBaseURL.
+	DefaultAccountBaseURL = "https://account.withings.com"
+)
+
+// Paths of the Withings services relative to either the account or API base URL.
+const (
+	PathUserAuthorize = "/oauth2_user/authorize2"
+	PathOAuth2        = "/v2/oauth2"
+	PathMeasure       = "/measure"
+	PathMeasureV2     = "/v2/measure"
+	PathHeartV2       = "/v2/heart"
+	PathSleepV2       = "/v2/sleep"
+	PathStethoV2      = "/v2/stetho"
+	PathNotify        = "/notify"
+	PathUserV2        = "/v2/user"
+	PathSignatureV2   = "/v2/signature"
+)
+
+// Full URLs of the Withings services on the default hosts. Clients resolve their requests against the configured
+// base URLs instead, these remain for reference.
+const (
+	APIPathUserAuthorize   = DefaultAccountBaseURL + PathUserAuthorize
+	APIPathUserAccessToken = DefaultAPIBaseURL + PathOAuth2
+	APIPathGetMeas         = DefaultAPIBaseURL + PathMeasure
+	APIPathGetV2Measure    = DefaultAPIBaseURL + PathMeasureV2
+	APIHeartV2             = DefaultAPIBaseURL + PathHeartV2
+	APISleepV2             = DefaultAPIBaseURL + PathSleepV2
+	APIStethoV2            = DefaultAPIBaseURL + PathStethoV2
+	APINotify              = DefaultAPIBaseURL + PathNotify
+	APIUser                = DefaultAPIBaseURL + PathUserV2
+	APISignatureV2         = DefaultAPIBaseURL + PathSignatureV2
 )
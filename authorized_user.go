@@ -2,6 +2,8 @@ package withings
 
 import (
 	"context"
+	"fmt"
+	"net/url"
 	"sync"
 	"time"
 )
@@ -11,46 +13,159 @@ type AuthorizedUser struct {
 	c *Client
 	t *AccessToken
 	sync.Mutex
+
+	// The store refreshed tokens are saved to under userID. If nil refreshed tokens are only kept in memory.
+	store  TokenStore
+	userID string
+
+	// Called with the old and new token every time the token is refreshed.
+	onTokenRefresh func(old AccessToken, new AccessToken)
+
+	// The last time the token was used for a request.
+	lastUsed time.Time
+
+	// Whether the access to the data of the user has been revoked. The token may no longer be used.
+	revoked bool
+}
+
+// AuthorizedUserOption is an option that can be applied to an AuthorizedUser.
+type AuthorizedUserOption func(a *AuthorizedUser)
+
+// WithTokenStore configures the user to save every refreshed token to the store under the Withings user ID provided.
+// This ensures the rotated refresh token is never lost, even if the token returned by the data methods is ignored. If
+// userID is empty the user ID of the token is used.
+func WithTokenStore(store TokenStore, userID string) AuthorizedUserOption {
+	return func(a *AuthorizedUser) {
+		a.store = store
+		a.userID = userID
+	}
+}
+
+// OnTokenRefresh configures the user to call fn with the old and new token every time the token is refreshed. This
+// allows persisting the rotated refresh token in one place instead of at every call site. fn is called exactly once per
+// refresh, even when the refresh is triggered by concurrent calls or performed by another AuthorizedUser of the same
+// Withings user, and must not call any methods of the user.
+func OnTokenRefresh(fn func(old AccessToken, new AccessToken)) AuthorizedUserOption {
+	return func(a *AuthorizedUser) {
+		a.onTokenRefresh = fn
+	}
 }
 
-func (c *Client) NewAuthorizedUser(t AccessToken) *AuthorizedUser {
-	return &AuthorizedUser{
+// NewAuthorizedUser returns a new AuthorizedUser accessing the API with the token provided.
+func (c *Client) NewAuthorizedUser(t AccessToken, opts ...AuthorizedUserOption) *AuthorizedUser {
+	a := &AuthorizedUser{
 		c: c,
 		t: &t,
 	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	// The user ID is known from either the token or the store option.
+	if a.t.UserID == "" {
+		a.t.UserID = a.userID
+	}
+	if a.userID == "" {
+		a.userID = a.t.UserID
+	}
+
+	return a
+}
+
+// LoadAuthorizedUser returns a new AuthorizedUser accessing the API with the token stored for the user in the store.
+// Refreshed tokens are saved back to the store. If no token is stored ErrTokenNotFound is returned.
+func (c *Client) LoadAuthorizedUser(ctx context.Context, store TokenStore, userID string, opts ...AuthorizedUserOption) (*AuthorizedUser, error) {
+	t, err := store.Load(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load token: %w", err)
+	}
+
+	return c.NewAuthorizedUser(*t, append([]AuthorizedUserOption{WithTokenStore(store, userID)}, opts...)...), nil
+}
+
+// Token returns the current token of the user.
+func (a *AuthorizedUser) Token() AccessToken {
+	a.Lock()
+	defer a.Unlock()
+
+	return *a.t
+}
+
+// usage returns the current token of the user and the last time it was used for a request.
+func (a *AuthorizedUser) usage() (AccessToken, time.Time) {
+	a.Lock()
+	defer a.Unlock()
+
+	return *a.t, a.lastUsed
+}
+
+// refresh refreshes the token unless it is valid beyond validUntil. Refreshed tokens are handled as by checkToken.
+func (a *AuthorizedUser) refresh(ctx context.Context, validUntil time.Time) (AccessToken, error) {
+	a.Lock()
+	defer a.Unlock()
+
+	t, _, err := a.refreshLocked(ctx, validUntil)
+	return t, err
 }
 
-// checkToken checks if the token is still valid and requests a new token if needed. If a new token
-// is obtained it is returned.
-func (a *AuthorizedUser) checkToken() (*AccessTokenResponse, error) {
+// checkToken checks if the token is still valid and requests a new token if needed. The token to use is returned
+// along with the token response if a new token was obtained. Refreshes are coordinated by the client so every
+// AuthorizedUser of the same Withings user shares the token obtained by the first. If the user has a TokenStore the
+// new token is saved to it and the OnTokenRefresh callback is called before returning.
+func (a *AuthorizedUser) checkToken(ctx context.Context) (AccessToken, *AccessTokenResponse, error) {
 
 	// Locking for the entire life of the call to prevent any other attempts with the token.
 	a.Lock()
 	defer a.Unlock()
 
-	expAt := a.t.ExpiresAt.Add(-10 * time.Second)
+	a.lastUsed = time.Now()
+	return a.refreshLocked(ctx, a.lastUsed.Add(tokenRefreshMargin))
+}
 
-	if time.Now().After(expAt) {
-		tokenResp, err := a.c.RefreshAccessToken(*a.t)
-		if err != nil {
-			return tokenResp, err
+// refreshLocked refreshes the token unless it is valid beyond validUntil. ErrRevoked is returned if the access of the
+// user has been revoked. The lock of the user must be held.
+func (a *AuthorizedUser) refreshLocked(ctx context.Context, validUntil time.Time) (AccessToken, *AccessTokenResponse, error) {
+	old := *a.t
+	if a.revoked {
+		return old, nil, ErrRevoked
+	}
+	t, tokenResp, err := a.c.tokens.token(ctx, old, a.store, validUntil)
+	if err != nil {
+		return old, tokenResp, err
+	}
+	if t.AccessToken == old.AccessToken {
+		return t, nil, nil
+	}
+
+	// The token may have been refreshed by another AuthorizedUser, in which case there is no response of our own.
+	a.t = &t
+	if tokenResp == nil {
+		tokenResp = &AccessTokenResponse{AccessToken: t}
+	}
+
+	if a.store != nil {
+		if err = a.store.Save(ctx, a.userID, t); err != nil {
+			return t, tokenResp, fmt.Errorf("failed to save refreshed token: %w", err)
 		}
-		a.t = &tokenResp.AccessToken
-		return tokenResp, nil
 	}
 
-	return nil, nil
+	if a.onTokenRefresh != nil {
+		a.onTokenRefresh(old, t)
+	}
+
+	return t, tokenResp, nil
 }
 
 // GetMeasure returns the measures for the AuthorizedUser based on the param provided. If a new token had to be created
 // it will be non nil.
 func (a *AuthorizedUser) GetMeasure(ctx context.Context, param GetMeasureParam) (*GetMeasureResp, *AccessToken, error) {
-	tokenResp, err := a.checkToken()
+	token, tokenResp, err := a.checkToken(ctx)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	resp, err := a.c.GetMeasure(ctx, *a.t, param)
+	resp, err := a.c.GetMeasure(ctx, token, param)
 
 	if tokenResp != nil {
 		return resp, &tokenResp.AccessToken, err
@@ -62,12 +177,12 @@ func (a *AuthorizedUser) GetMeasure(ctx context.Context, param GetMeasureParam)
 // GetIntraDayActivity returns the intra day activities for the AuthorizedUser based on the param provided. If a new token had to be created
 // it will be non nil.
 func (a *AuthorizedUser) GetIntraDayActivity(ctx context.Context, param GetIntraDayActivityParam) (*GetIntraDayActivityResp, *AccessToken, error) {
-	tokenResp, err := a.checkToken()
+	token, tokenResp, err := a.checkToken(ctx)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	resp, err := a.c.GetIntraDayActivity(ctx, *a.t, param)
+	resp, err := a.c.GetIntraDayActivity(ctx, token, param)
 
 	if tokenResp != nil {
 		return resp, &tokenResp.AccessToken, err
@@ -79,12 +194,12 @@ func (a *AuthorizedUser) GetIntraDayActivity(ctx context.Context, param GetIntra
 // GetActivity returns the activities for the AuthorizedUser based on the param provided. If a new token had to be created
 // it will be non nil.
 func (a *AuthorizedUser) GetActivity(ctx context.Context, param GetActivityParam) (*GetActivityResp, *AccessToken, error) {
-	tokenResp, err := a.checkToken()
+	token, tokenResp, err := a.checkToken(ctx)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	resp, err := a.c.GetActivity(ctx, *a.t, param)
+	resp, err := a.c.GetActivity(ctx, token, param)
 
 	if tokenResp != nil {
 		return resp, &tokenResp.AccessToken, err
@@ -96,12 +211,12 @@ func (a *AuthorizedUser) GetActivity(ctx context.Context, param GetActivityParam
 // GetHeartList returns the Heart Data for the AuthorizedUser based on the param provided. If a new token had to be created
 // it will be non nil.
 func (a *AuthorizedUser) GetHeartList(ctx context.Context, param GetHeartListParam) (*GetHeartResp, *AccessToken, error) {
-	tokenResp, err := a.checkToken()
+	token, tokenResp, err := a.checkToken(ctx)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	resp, err := a.c.GetHeartList(ctx, *a.t, param)
+	resp, err := a.c.GetHeartList(ctx, token, param)
 
 	if tokenResp != nil {
 		return resp, &tokenResp.AccessToken, err
@@ -113,12 +228,46 @@ func (a *AuthorizedUser) GetHeartList(ctx context.Context, param GetHeartListPar
 // GetHeartHighFrequencyData returns the Heart Data for the AuthorizedUser based on the param provided. If a new token had to be created
 // it will be non nil.
 func (a *AuthorizedUser) GetHeartHighFrequencyData(ctx context.Context, param GetHeartHighFrequencyDataParam) (*GetHeartHighFrequencyDataResp, *AccessToken, error) {
-	tokenResp, err := a.checkToken()
+	token, tokenResp, err := a.checkToken(ctx)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	resp, err := a.c.GetHeartHighFrequencyData(ctx, *a.t, param)
+	resp, err := a.c.GetHeartHighFrequencyData(ctx, token, param)
+
+	if tokenResp != nil {
+		return resp, &tokenResp.AccessToken, err
+	}
+
+	return resp, nil, err
+}
+
+// GetStethoList returns the stethoscope recordings for the AuthorizedUser based on the param provided. If a new token
+// had to be created it will be non nil.
+func (a *AuthorizedUser) GetStethoList(ctx context.Context, param GetStethoListParam) (*GetStethoListResp, *AccessToken, error) {
+	token, tokenResp, err := a.checkToken(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := a.c.GetStethoList(ctx, token, param)
+
+	if tokenResp != nil {
+		return resp, &tokenResp.AccessToken, err
+	}
+
+	return resp, nil, err
+}
+
+// GetStethoSignal returns the audio signal of a stethoscope recording for the AuthorizedUser based on the param
+// provided. If a new token had to be created it will be non nil.
+func (a *AuthorizedUser) GetStethoSignal(ctx context.Context, param GetStethoSignalParam) (*GetStethoSignalResp, *AccessToken, error) {
+	token, tokenResp, err := a.checkToken(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := a.c.GetStethoSignal(ctx, token, param)
 
 	if tokenResp != nil {
 		return resp, &tokenResp.AccessToken, err
@@ -130,12 +279,12 @@ func (a *AuthorizedUser) GetHeartHighFrequencyData(ctx context.Context, param Ge
 // GetSleep returns the Sleep data for the AuthorizedUser based on the param provided. If a new token had to be created
 // it will be non nil.
 func (a *AuthorizedUser) GetSleep(ctx context.Context, param GetSleepParam) (*GetSleepResp, *AccessToken, error) {
-	tokenResp, err := a.checkToken()
+	token, tokenResp, err := a.checkToken(ctx)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	resp, err := a.c.GetSleep(ctx, *a.t, param)
+	resp, err := a.c.GetSleep(ctx, token, param)
 
 	if tokenResp != nil {
 		return resp, &tokenResp.AccessToken, err
@@ -147,12 +296,12 @@ func (a *AuthorizedUser) GetSleep(ctx context.Context, param GetSleepParam) (*Ge
 // GetSleepSummary returns the SleepSummary data for the AuthorizedUser based on the param provided. If a new token had to be created
 // it will be non nil.
 func (a *AuthorizedUser) GetSleepSummary(ctx context.Context, param GetSleepSummaryParam) (*GetSleepSummaryResp, *AccessToken, error) {
-	tokenResp, err := a.checkToken()
+	token, tokenResp, err := a.checkToken(ctx)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	resp, err := a.c.GetSleepSummary(ctx, *a.t, param)
+	resp, err := a.c.GetSleepSummary(ctx, token, param)
 
 	if tokenResp != nil {
 		return resp, &tokenResp.AccessToken, err
@@ -164,12 +313,131 @@ func (a *AuthorizedUser) GetSleepSummary(ctx context.Context, param GetSleepSumm
 // GetWorkout returns the Workout data for the AuthorizedUser based on the param provided. If a new token had to be created
 // it will be non nil.
 func (a *AuthorizedUser) GetWorkout(ctx context.Context, param GetWorkoutParam) (*GetWorkoutResp, *AccessToken, error) {
-	tokenResp, err := a.checkToken()
+	token, tokenResp, err := a.checkToken(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := a.c.GetWorkout(ctx, token, param)
+
+	if tokenResp != nil {
+		return resp, &tokenResp.AccessToken, err
+	}
+
+	return resp, nil, err
+}
+
+// GetUserDevice returns the devices linked to the AuthorizedUser. If a new token had to be created
+// it will be non nil.
+func (a *AuthorizedUser) GetUserDevice(ctx context.Context) (*GetUserDeviceResp, *AccessToken, error) {
+	token, tokenResp, err := a.checkToken(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := a.c.GetUserDevice(ctx, token)
+
+	if tokenResp != nil {
+		return resp, &tokenResp.AccessToken, err
+	}
+
+	return resp, nil, err
+}
+
+// SubscribeToNotifications subscribes the AuthorizedUser to the notifications described by the param provided. If a new token had to be created
+// it will be non nil.
+func (a *AuthorizedUser) SubscribeToNotifications(ctx context.Context, param SubscribeToNotificationsParam) (*SubscribeToNotificationsResp, *AccessToken, error) {
+	token, tokenResp, err := a.checkToken(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := a.c.SubscribeToNotifications(ctx, token, param)
+
+	if tokenResp != nil {
+		return resp, &tokenResp.AccessToken, err
+	}
+
+	return resp, nil, err
+}
+
+// GetNotification returns the notification subscription of the AuthorizedUser matching the param provided. If a new token had to be created
+// it will be non nil.
+func (a *AuthorizedUser) GetNotification(ctx context.Context, param GetNotificationParam) (*GetNotificationResp, *AccessToken, error) {
+	token, tokenResp, err := a.checkToken(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := a.c.GetNotification(ctx, token, param)
+
+	if tokenResp != nil {
+		return resp, &tokenResp.AccessToken, err
+	}
+
+	return resp, nil, err
+}
+
+// ListNotification lists the notification subscriptions of the AuthorizedUser based on the param provided. If a new token had to be created
+// it will be non nil.
+func (a *AuthorizedUser) ListNotification(ctx context.Context, param ListNotificationParam) (*ListNotificationResp, *AccessToken, error) {
+	token, tokenResp, err := a.checkToken(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := a.c.ListNotification(ctx, token, param)
+
+	if tokenResp != nil {
+		return resp, &tokenResp.AccessToken, err
+	}
+
+	return resp, nil, err
+}
+
+// RevokeNotification revokes the notification subscription of the AuthorizedUser matching the param provided. If a new token had to be created
+// it will be non nil.
+func (a *AuthorizedUser) RevokeNotification(ctx context.Context, param RevokeNotificationParam) (*RevokeNotificationResp, *AccessToken, error) {
+	token, tokenResp, err := a.checkToken(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := a.c.RevokeNotification(ctx, token, param)
+
+	if tokenResp != nil {
+		return resp, &tokenResp.AccessToken, err
+	}
+
+	return resp, nil, err
+}
+
+// UpdateNotification updates the notification subscription of the AuthorizedUser based on the param provided. If a new token had to be created
+// it will be non nil.
+func (a *AuthorizedUser) UpdateNotification(ctx context.Context, param UpdateNotificationParam) (*UpdateNotificationResp, *AccessToken, error) {
+	token, tokenResp, err := a.checkToken(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := a.c.UpdateNotification(ctx, token, param)
+
+	if tokenResp != nil {
+		return resp, &tokenResp.AccessToken, err
+	}
+
+	return resp, nil, err
+}
+
+// GetUserGoals returns the goals of the AuthorizedUser. If a new token had to be created
+// it will be non nil.
+func (a *AuthorizedUser) GetUserGoals(ctx context.Context) (*GetUserGoalsResp, *AccessToken, error) {
+	token, tokenResp, err := a.checkToken(ctx)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	resp, err := a.c.GetWorkout(ctx, *a.t, param)
+	resp, err := a.c.GetUserGoals(ctx, token)
 
 	if tokenResp != nil {
 		return resp, &tokenResp.AccessToken, err
@@ -177,3 +445,68 @@ func (a *AuthorizedUser) GetWorkout(ctx context.Context, param GetWorkoutParam)
 
 	return resp, nil, err
 }
+
+// Revoke revokes the access of the client to the data of the AuthorizedUser, unlinking the user from the application.
+// The notification subscriptions of the user are revoked first, then the access is revoked and the token is deleted
+// from the TokenStore of the user, if any. If revoking a subscription fails the access is left in place so Revoke can
+// be called again. Once revoked every method of the AuthorizedUser using the token returns ErrRevoked, calling Revoke
+// again only retries deleting the token from the TokenStore.
+func (a *AuthorizedUser) Revoke(ctx context.Context) error {
+	if a.userID == "" {
+		return ErrUnknownUser
+	}
+
+	a.Lock()
+	revoked := a.revoked
+	a.Unlock()
+
+	if !revoked {
+		if err := a.revoke(ctx); err != nil {
+			return err
+		}
+	}
+
+	if a.store != nil {
+		if err := a.store.Delete(ctx, a.userID); err != nil {
+			return fmt.Errorf("failed to delete token: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// revoke revokes the notification subscriptions of every category and then the access of the user, marking the user
+// as revoked.
+func (a *AuthorizedUser) revoke(ctx context.Context) error {
+	token, _, err := a.checkToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	list, err := a.c.listAllNotifications(ctx, token)
+	if err != nil {
+		return fmt.Errorf("failed to list notifications: %w", err)
+	}
+	for _, n := range list.Body.Profiles {
+		callbackURL, err := url.Parse(n.Callbackurl)
+		if err != nil {
+			return fmt.Errorf("failed to parse notification callback url: %w", err)
+		}
+		if _, _, err = a.RevokeNotification(ctx, RevokeNotificationParam{Appli: n.Appli, CallbackURL: *callbackURL}); err != nil {
+			return fmt.Errorf("failed to revoke notification: %w", err)
+		}
+	}
+
+	if _, err = a.c.RevokeUser(ctx, a.userID); err != nil {
+		return fmt.Errorf("failed to revoke access: %w", err)
+	}
+
+	a.Lock()
+	a.revoked = true
+	a.Unlock()
+
+	// The tokens of the user are no longer valid and must not be shared with other instances.
+	a.c.tokens.forget(a.userID)
+
+	return nil
+}
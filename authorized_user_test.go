@@ -0,0 +1,220 @@
+package withings_test
+
+import (
+	"context"
+	"net/url"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jrmycanady/withings"
+	"github.com/jrmycanady/withings/withingstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthorizedUser_OnTokenRefresh(t *testing.T) {
+	t.Parallel()
+
+	srv := withingstest.NewServer()
+	defer srv.Close()
+	srv.AddUser(withingstest.NewDemoUser("1", time.Now()))
+	c := srv.NewClient(url.URL{})
+	ctx := context.Background()
+
+	token := srv.IssueToken("1")
+	token.ExpiresAt = time.Now().Add(-time.Minute)
+
+	var mu sync.Mutex
+	var refreshes []withings.AccessToken
+	u := c.NewAuthorizedUser(token, withings.OnTokenRefresh(func(old withings.AccessToken, new withings.AccessToken) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		assert.Equal(t, token.RefreshToken, old.RefreshToken)
+		refreshes = append(refreshes, new)
+	}))
+	api := u.API()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			resp, err := api.GetMeasure(ctx, withings.GetMeasureParam{})
+			assert.Nil(t, err)
+			assert.NotNil(t, resp)
+		}()
+	}
+	wg.Wait()
+
+	require.Len(t, refreshes, 1)
+	assert.Equal(t, refreshes[0], u.Token())
+	assert.NotEqual(t, token.RefreshToken, refreshes[0].RefreshToken)
+	assert.Equal(t, 1, srv.RequestCount(withings.PathOAuth2, "requesttoken"))
+}
+
+func TestUserAPI(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	srv := withingstest.NewServer()
+	defer srv.Close()
+	srv.AddUser(withingstest.NewDemoUser("1", now))
+	c := srv.NewClient(url.URL{})
+	api := c.NewAuthorizedUser(srv.IssueToken("1")).API()
+	ctx := context.Background()
+	start := now.Add(-7 * 24 * time.Hour)
+
+	measures, err := api.GetMeasure(ctx, withings.GetMeasureParam{})
+	require.Nil(t, err)
+	assert.Len(t, measures.Body.MeasureGroups, 7)
+
+	activities, err := api.GetActivity(ctx, withings.GetActivityParam{})
+	require.Nil(t, err)
+	assert.Len(t, activities.Body.Activities, 7)
+
+	intraDay, err := api.GetIntraDayActivityChunked(ctx, withings.GetIntraDayActivityParam{StartDate: &start, EndDate: &now}, withings.ChunkOptions{})
+	require.Nil(t, err)
+	assert.Len(t, intraDay.Body.Series, 42)
+
+	sleeps, err := api.GetSleep(ctx, withings.GetSleepParam{StartDate: start, EndDate: now})
+	require.Nil(t, err)
+	assert.Len(t, sleeps.Body.Series, 7)
+
+	workouts, err := api.GetWorkout(ctx, withings.GetWorkoutParam{})
+	require.Nil(t, err)
+	assert.Len(t, workouts.Body.Series, 7)
+}
+
+// tokenMethods are the methods of Client taking a token that manage the token itself and therefore have no
+// AuthorizedUser counterpart.
+var tokenMethods = map[string]bool{
+	"RefreshAccessToken":        true,
+	"RefreshAccessTokenContext": true,
+	"NewAuthorizedUser":         true,
+	"ForToken":                  true,
+}
+
+func TestAuthorizedUser_ClientParity(t *testing.T) {
+	t.Parallel()
+
+	tokenType := reflect.TypeOf(withings.AccessToken{})
+	accessTokenType := reflect.TypeOf(&withings.AccessToken{})
+	clientType := reflect.TypeOf(&withings.Client{})
+	userType := reflect.TypeOf(&withings.AuthorizedUser{})
+	apiType := reflect.TypeOf(&withings.UserAPI{})
+
+	// Every method of Client taking a token must have an AuthorizedUser counterpart taking the same parameters
+	// without the token.
+	for i := 0; i < clientType.NumMethod(); i++ {
+		method := clientType.Method(i)
+
+		var in []reflect.Type
+		takesToken := false
+		for j := 1; j < method.Type.NumIn(); j++ {
+			if method.Type.In(j) == tokenType {
+				takesToken = true
+				continue
+			}
+			in = append(in, method.Type.In(j))
+		}
+		if !takesToken || tokenMethods[method.Name] {
+			continue
+		}
+
+		counterpart, ok := userType.MethodByName(method.Name)
+		if !assert.True(t, ok, "AuthorizedUser has no %s method", method.Name) {
+			continue
+		}
+		var counterpartIn []reflect.Type
+		for j := 1; j < counterpart.Type.NumIn(); j++ {
+			counterpartIn = append(counterpartIn, counterpart.Type.In(j))
+		}
+		assert.Equal(t, in, counterpartIn, "parameters of AuthorizedUser.%s", method.Name)
+	}
+
+	// Every method of AuthorizedUser returning a refreshed token must have a UserAPI counterpart without it.
+	for i := 0; i < userType.NumMethod(); i++ {
+		method := userType.Method(i)
+
+		var out []reflect.Type
+		returnsToken := false
+		for j := 0; j < method.Type.NumOut(); j++ {
+			if method.Type.Out(j) == accessTokenType {
+				returnsToken = true
+				continue
+			}
+			out = append(out, method.Type.Out(j))
+		}
+		if !returnsToken {
+			continue
+		}
+
+		counterpart, ok := apiType.MethodByName(method.Name)
+		if !assert.True(t, ok, "UserAPI has no %s method", method.Name) {
+			continue
+		}
+		var counterpartOut []reflect.Type
+		for j := 0; j < counterpart.Type.NumOut(); j++ {
+			counterpartOut = append(counterpartOut, counterpart.Type.Out(j))
+		}
+		assert.Equal(t, out, counterpartOut, "results of UserAPI.%s", method.Name)
+	}
+}
+
+func TestUserAPI_Notifications(t *testing.T) {
+	t.Parallel()
+
+	srv := withingstest.NewServer()
+	defer srv.Close()
+	srv.AddUser(withingstest.NewDemoUser("1", time.Now()))
+	c := srv.NewClient(url.URL{})
+	ctx := context.Background()
+
+	// The token is expired so every call must refresh it first.
+	token := srv.IssueToken("1")
+	token.ExpiresAt = time.Now().Add(-time.Minute)
+	api := c.NewAuthorizedUser(token).API()
+
+	callbackURL := url.URL{Scheme: "https", Host: "example.com", Path: "/notify"}
+	_, err := api.SubscribeToNotifications(ctx, withings.SubscribeToNotificationsParam{CallbackURL: callbackURL, Appli: 1})
+	require.Nil(t, err)
+
+	list, err := api.ListNotification(ctx, withings.ListNotificationParam{Appli: 1})
+	require.Nil(t, err)
+	require.Len(t, list.Body.Profiles, 1)
+
+	_, err = api.RevokeNotification(ctx, withings.RevokeNotificationParam{CallbackURL: callbackURL, Appli: 1})
+	require.Nil(t, err)
+
+	_, err = api.GetUserDevice(ctx)
+	require.Nil(t, err)
+
+	assert.Equal(t, 1, srv.RequestCount(withings.PathOAuth2, "requesttoken"))
+}
+
+func TestClient_ForToken(t *testing.T) {
+	t.Parallel()
+
+	srv := withingstest.NewServer()
+	defer srv.Close()
+	srv.AddUser(withingstest.NewDemoUser("1", time.Now()))
+	c := srv.NewClient(url.URL{})
+	ctx := context.Background()
+
+	var api withings.DataAPI = c.ForToken(srv.IssueToken("1"))
+	measures, err := api.GetMeasure(ctx, withings.GetMeasureParam{})
+	require.Nil(t, err)
+	assert.Len(t, measures.Body.MeasureGroups, 7)
+
+	// The token is used as is, even if expired.
+	token := srv.IssueToken("1")
+	token.ExpiresAt = time.Now().Add(-time.Minute)
+	srv.ExpireToken(token.AccessToken)
+	_, err = c.ForToken(token).GetWorkout(ctx, withings.GetWorkoutParam{})
+	assert.ErrorIs(t, err, withings.ErrInvalidToken)
+	assert.Equal(t, 0, srv.RequestCount(withings.PathOAuth2, "requesttoken"))
+}
@@ -0,0 +1,245 @@
+package withings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// MaxIntraDayActivityWindow is the longest time range the API returns intra day activities for in one request.
+	MaxIntraDayActivityWindow = 24 * time.Hour
+
+	// MaxSleepWindow is the longest time range the API returns sleep data for in one request.
+	MaxSleepWindow = 7 * 24 * time.Hour
+)
+
+// ErrInvalidRange is returned when the time range of a chunked request is missing or ends before it starts.
+var ErrInvalidRange = errors.New("invalid time range")
+
+// ChunkOptions configures how a long time range is split into windows the API accepts.
+type ChunkOptions struct {
+	// The length of each window. If zero or longer than the maximum the API accepts, the maximum is used.
+	Window time.Duration
+
+	// The number of windows fetched concurrently. Values less than 2 fetch the windows one after another. Concurrent
+	// requests still wait on the rate limiter of the client.
+	Concurrency int
+}
+
+// window is a time range of a chunked request.
+type window struct {
+	start time.Time
+	end   time.Time
+}
+
+// splitWindows splits the range from start to end into consecutive windows no longer than size. ErrInvalidRange is
+// returned if end is before start.
+func splitWindows(start time.Time, end time.Time, size time.Duration) ([]window, error) {
+	if end.Before(start) {
+		return nil, fmt.Errorf("%w: end %s is before start %s", ErrInvalidRange, end, start)
+	}
+
+	windows := make([]window, 0, int(end.Sub(start)/size)+1)
+	for s := start; s.Before(end); s = s.Add(size) {
+		e := s.Add(size)
+		if e.After(end) {
+			e = end
+		}
+		windows = append(windows, window{start: s, end: e})
+	}
+	return windows, nil
+}
+
+// windowSize returns the window size to use based on the options and the API maximum.
+func (o ChunkOptions) windowSize(max time.Duration) time.Duration {
+	if o.Window <= 0 || o.Window > max {
+		return max
+	}
+	return o.Window
+}
+
+// fetchWindows calls fetch for every window, running up to concurrency calls at once. The first error cancels the
+// remaining calls and is returned.
+func fetchWindows(ctx context.Context, windows []window, concurrency int, fetch func(ctx context.Context, i int, w window) error) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	fetchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	sem := make(chan struct{}, concurrency)
+
+	for i, w := range windows {
+		select {
+		case sem <- struct{}{}:
+		case <-fetchCtx.Done():
+		}
+		if fetchCtx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(i int, w window) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if err := fetch(fetchCtx, i, w); err != nil {
+				once.Do(func() {
+					firstErr = fmt.Errorf("failed to fetch window %s to %s: %w", w.start, w.end, err)
+					cancel()
+				})
+			}
+		}(i, w)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+
+	// The context may have ended before every window was started.
+	return ctx.Err()
+}
+
+// GetIntraDayActivityChunked retrieves intra day activities for the user represented by the token over a range longer
+// than the API allows in one request. The range between StartDate and EndDate, both of which are required, is split
+// into windows that are fetched separately and merged. Activities at the boundaries of windows are only included once.
+// ErrInvalidRange is returned if a date is missing or EndDate is before StartDate.
+func (c *Client) GetIntraDayActivityChunked(ctx context.Context, token AccessToken, param GetIntraDayActivityParam, opts ChunkOptions) (*GetIntraDayActivityResp, error) {
+	if param.StartDate == nil || param.EndDate == nil {
+		return nil, fmt.Errorf("%w: start and end date are required to chunk a request", ErrInvalidRange)
+	}
+
+	windows, err := splitWindows(*param.StartDate, *param.EndDate, opts.windowSize(MaxIntraDayActivityWindow))
+	if err != nil {
+		return nil, err
+	}
+	results := make([]IntraDayActivities, len(windows))
+
+	err = fetchWindows(ctx, windows, opts.Concurrency, func(ctx context.Context, i int, w window) error {
+		p := param
+		p.StartDate = &w.start
+		p.EndDate = &w.end
+
+		resp, err := c.GetIntraDayActivity(ctx, token, p)
+		if err != nil {
+			return err
+		}
+		results[i] = resp.Body.Series
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	merged := GetIntraDayActivityResp{
+		Body: GetIntraDayActivityBody{
+			Series: make(IntraDayActivities),
+		},
+	}
+	for _, series := range results {
+		for ts, activity := range series {
+			merged.Body.Series[ts] = activity
+		}
+	}
+
+	return &merged, nil
+}
+
+// GetSleepChunked retrieves sleep data for the user represented by the token over a range longer than the API allows
+// in one request. The range between StartDate and EndDate, both of which are required, is split into windows that are
+// fetched separately and merged in order of their start date. Sleep records returned by more than one window are only
+// included once. ErrInvalidRange is returned if a date is zero or EndDate is before StartDate.
+func (c *Client) GetSleepChunked(ctx context.Context, token AccessToken, param GetSleepParam, opts ChunkOptions) (*GetSleepResp, error) {
+	if param.StartDate.IsZero() || param.EndDate.IsZero() {
+		return nil, fmt.Errorf("%w: start and end date are required to chunk a request", ErrInvalidRange)
+	}
+
+	windows, err := splitWindows(param.StartDate, param.EndDate, opts.windowSize(MaxSleepWindow))
+	if err != nil {
+		return nil, err
+	}
+	results := make([]Sleeps, len(windows))
+
+	err = fetchWindows(ctx, windows, opts.Concurrency, func(ctx context.Context, i int, w window) error {
+		p := param
+		p.StartDate = w.start
+		p.EndDate = w.end
+
+		resp, err := c.GetSleep(ctx, token, p)
+		if err != nil {
+			return err
+		}
+		results[i] = resp.Body.Series
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	type sleepKey struct {
+		startDate int
+		endDate   int
+		state     int
+	}
+	seen := make(map[sleepKey]bool)
+
+	var merged GetSleepResp
+	for _, series := range results {
+		for _, s := range series {
+			k := sleepKey{startDate: s.StartDate, endDate: s.EndDate, state: s.State}
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+			merged.Body.Series = append(merged.Body.Series, s)
+		}
+	}
+	sort.SliceStable(merged.Body.Series, func(i, j int) bool {
+		return merged.Body.Series[i].StartDate < merged.Body.Series[j].StartDate
+	})
+
+	return &merged, nil
+}
+
+// GetIntraDayActivityChunked returns the intra day activities for the AuthorizedUser over a range longer than the API
+// allows in one request. See Client.GetIntraDayActivityChunked. If a new token had to be created it will be non nil.
+func (a *AuthorizedUser) GetIntraDayActivityChunked(ctx context.Context, param GetIntraDayActivityParam, opts ChunkOptions) (*GetIntraDayActivityResp, *AccessToken, error) {
+	token, tokenResp, err := a.checkToken(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := a.c.GetIntraDayActivityChunked(ctx, token, param, opts)
+
+	if tokenResp != nil {
+		return resp, &tokenResp.AccessToken, err
+	}
+
+	return resp, nil, err
+}
+
+// GetSleepChunked returns the sleep data for the AuthorizedUser over a range longer than the API allows in one
+// request. See Client.GetSleepChunked. If a new token had to be created it will be non nil.
+func (a *AuthorizedUser) GetSleepChunked(ctx context.Context, param GetSleepParam, opts ChunkOptions) (*GetSleepResp, *AccessToken, error) {
+	token, tokenResp, err := a.checkToken(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := a.c.GetSleepChunked(ctx, token, param, opts)
+
+	if tokenResp != nil {
+		return resp, &tokenResp.AccessToken, err
+	}
+
+	return resp, nil, err
+}
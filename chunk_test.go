@@ -0,0 +1,176 @@
+package withings_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jrmycanady/withings"
+	"github.com/jrmycanady/withings/withingstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_GetIntraDayActivityChunked(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	srv := withingstest.NewServer()
+	defer srv.Close()
+	srv.AddUser(withingstest.NewDemoUser("1", now))
+	c := srv.NewClient(url.URL{})
+	token := srv.IssueToken("1")
+	ctx := context.Background()
+
+	start := now.Add(-7 * 24 * time.Hour)
+	param := withings.GetIntraDayActivityParam{
+		StartDate: &start,
+		EndDate:   &now,
+	}
+
+	single, err := c.GetIntraDayActivity(ctx, token, param)
+	require.Nil(t, err)
+	assert.Len(t, single.Body.Series, 6, "the API only returns a single day")
+
+	tests := []struct {
+		name    string
+		opts    withings.ChunkOptions
+		windows int
+	}{
+		{name: "Sequential", opts: withings.ChunkOptions{}, windows: 7},
+		{name: "Concurrent", opts: withings.ChunkOptions{Concurrency: 4}, windows: 7},
+		{name: "Smaller window", opts: withings.ChunkOptions{Window: 12 * time.Hour}, windows: 14},
+		{name: "Window above maximum", opts: withings.ChunkOptions{Window: 48 * time.Hour}, windows: 7},
+	}
+
+	for _, test := range tests {
+		before := srv.RequestCount(withings.PathMeasureV2, withings.APIActionGetIntraDayActivity)
+
+		resp, err := c.GetIntraDayActivityChunked(ctx, token, param, test.opts)
+		require.Nil(t, err, test.name)
+		assert.Len(t, resp.Body.Series, 42, test.name)
+
+		after := srv.RequestCount(withings.PathMeasureV2, withings.APIActionGetIntraDayActivity)
+		assert.Equal(t, test.windows, after-before, test.name)
+	}
+
+	_, err = c.GetIntraDayActivityChunked(ctx, token, withings.GetIntraDayActivityParam{}, withings.ChunkOptions{})
+	assert.NotNil(t, err, "dates are required")
+}
+
+func TestClient_GetSleepChunked(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	srv := withingstest.NewServer()
+	defer srv.Close()
+	srv.AddUser(withingstest.NewDemoUser("1", now))
+	c := srv.NewClient(url.URL{})
+	token := srv.IssueToken("1")
+	ctx := context.Background()
+
+	// Starting at a bedtime so every sleep starts on the boundary of two windows.
+	param := withings.GetSleepParam{
+		StartDate: now.Add(-7*24*time.Hour - 8*time.Hour),
+		EndDate:   now,
+	}
+
+	for _, concurrency := range []int{0, 3} {
+		resp, err := c.GetSleepChunked(ctx, token, param, withings.ChunkOptions{Window: 24 * time.Hour, Concurrency: concurrency})
+		require.Nil(t, err)
+		require.Len(t, resp.Body.Series, 7)
+		for i := 1; i < len(resp.Body.Series); i++ {
+			assert.Less(t, resp.Body.Series[i-1].StartDate, resp.Body.Series[i].StartDate)
+		}
+	}
+
+	t.Run("Longer than the maximum window", func(t *testing.T) {
+		param := withings.GetSleepParam{
+			StartDate: now.Add(-21 * 24 * time.Hour),
+			EndDate:   now,
+		}
+
+		single, err := c.GetSleep(ctx, token, param)
+		require.Nil(t, err)
+		assert.Len(t, single.Body.Series, 0)
+
+		resp, err := c.GetSleepChunked(ctx, token, param, withings.ChunkOptions{})
+		require.Nil(t, err)
+		assert.Len(t, resp.Body.Series, 7)
+	})
+
+	t.Run("Fails on error", func(t *testing.T) {
+		srv.Fail(withingstest.Failure{Path: withings.PathSleepV2, Status: withings.StatusInvalidParams})
+
+		resp, err := c.GetSleepChunked(ctx, token, param, withings.ChunkOptions{Window: 24 * time.Hour, Concurrency: 2})
+		assert.Nil(t, resp)
+		assert.True(t, errors.Is(err, withings.ErrInvalidParams))
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+
+		_, err := c.GetSleepChunked(ctx, token, param, withings.ChunkOptions{})
+		assert.True(t, errors.Is(err, context.Canceled))
+	})
+}
+
+func TestClient_ChunkedBounds(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	srv := withingstest.NewServer()
+	defer srv.Close()
+	srv.AddUser(withingstest.NewDemoUser("1", now))
+	c := srv.NewClient(url.URL{})
+	token := srv.IssueToken("1")
+	ctx := context.Background()
+
+	weekAgo := now.Add(-7 * 24 * time.Hour)
+
+	tests := []struct {
+		name  string
+		start time.Time
+		end   time.Time
+		err   error
+	}{
+		{name: "Reversed", start: now, end: weekAgo, err: withings.ErrInvalidRange},
+		{name: "Reversed by less than a window", start: now, end: now.Add(-time.Minute), err: withings.ErrInvalidRange},
+		{name: "Zero end", start: weekAgo, err: withings.ErrInvalidRange},
+		{name: "Zero start", end: now, err: withings.ErrInvalidRange},
+		{name: "Equal", start: now, end: now},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sleep, err := c.GetSleepChunked(ctx, token, withings.GetSleepParam{StartDate: tt.start, EndDate: tt.end}, withings.ChunkOptions{})
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				require.Nil(t, err)
+				assert.Empty(t, sleep.Body.Series)
+			}
+
+			param := withings.GetIntraDayActivityParam{}
+			if !tt.start.IsZero() {
+				param.StartDate = &tt.start
+			}
+			if !tt.end.IsZero() {
+				param.EndDate = &tt.end
+			}
+			activity, err := c.GetIntraDayActivityChunked(ctx, token, param, withings.ChunkOptions{})
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				require.Nil(t, err)
+				assert.Empty(t, activity.Body.Series)
+			}
+		})
+	}
+}
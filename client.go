@@ -1,13 +1,13 @@
 package withings
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/tls"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"net/url"
 	"strings"
@@ -29,12 +29,17 @@ const (
 	APIActionGetHeartGet           = "get"
 	APIActionGetSleep              = "get"
 	APIActionGetSleepSummary       = "getsummary"
+	APIActionGetStethoList         = "list"
+	APIActionGetStethoSignal       = "get"
 	APIActionNotificationSubscribe = "subscribe"
 	APIActionNotificationGet       = "get"
 	APIActionNotificationList      = "list"
 	APIActionNotificationRevoke    = "revoke"
 	APIActionNotificationUpdate    = "update"
 	APIActionUserGetDevice         = "getdevice"
+	APIActionUserGetGoals          = "getgoals"
+	APIActionGetNonce              = "getnonce"
+	APIActionRevoke                = "revoke"
 )
 
 type Client struct {
@@ -60,6 +65,27 @@ type Client struct {
 
 	// Denotes the client should run in demo mode.
 	demoMode bool
+
+	// Contains the base URL that all data API and token requests are resolved against.
+	apiBaseURL url.URL
+
+	// Contains the base URL that the user authorization URL is resolved against.
+	accountBaseURL url.URL
+
+	// Contains the policy used to retry requests that failed with a temporary error.
+	retryPolicy RetryPolicy
+
+	// Contains the limiter every request waits on before being sent. It is nil if no rate limit is configured.
+	rateLimiter *rateLimiter
+
+	// Contains the logger request events are emitted to. It is nil if no logger is configured.
+	logger Logger
+
+	// Contains the middlewares every API call passes through, outermost first.
+	middlewares []Middleware
+
+	// Contains the latest tokens of the users of every AuthorizedUser created from the client.
+	tokens *tokenManager
 }
 
 // RedirectURL provides the redirect URL the client is configured for. It cannot be changed once the client is created.
@@ -67,11 +93,51 @@ func (c *Client) RedirectURL() url.URL {
 	return c.redirectURL
 }
 
+// APIBaseURL provides the base URL the client resolves data API and token requests against.
+func (c *Client) APIBaseURL() url.URL {
+	return c.apiBaseURL
+}
+
+// AccountBaseURL provides the base URL the client resolves the user authorization URL against.
+func (c *Client) AccountBaseURL() url.URL {
+	return c.accountBaseURL
+}
+
+// apiURL returns the full URL of the API service at path.
+func (c *Client) apiURL(path string) string {
+	return resolveURL(c.apiBaseURL, path)
+}
+
+// accountURL returns the full URL of the account service at path.
+func (c *Client) accountURL(path string) string {
+	return resolveURL(c.accountBaseURL, path)
+}
+
+// resolveURL appends path to the path of base. Any query or fragment of base is dropped.
+func resolveURL(base url.URL, path string) string {
+	base.Path = strings.TrimSuffix(base.Path, "/") + path
+	base.RawPath = ""
+	base.RawQuery = ""
+	base.Fragment = ""
+	return base.String()
+}
+
+// mustParseURL parses rawURL and panics if it is not valid. It is only used for the package defaults.
+func mustParseURL(rawURL string) url.URL {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		panic(err)
+	}
+	return *u
+}
+
 func NewClient(clientID string, clientSecret string, redirectURL url.URL, opts ...ClientOption) *Client {
 	c := &Client{
-		clientID:     clientID,
-		clientSecret: clientSecret,
-		redirectURL:  redirectURL,
+		clientID:       clientID,
+		clientSecret:   clientSecret,
+		redirectURL:    redirectURL,
+		apiBaseURL:     mustParseURL(DefaultAPIBaseURL),
+		accountBaseURL: mustParseURL(DefaultAccountBaseURL),
 	}
 
 	// Apply options.
@@ -79,6 +145,8 @@ func NewClient(clientID string, clientSecret string, redirectURL url.URL, opts .
 		opt(c)
 	}
 
+	c.tokens = newTokenManager(c)
+
 	// Building the default http client with specified values.
 	c.HttpClient = &http.Client{
 		Timeout: c.httpClientTimeout,
@@ -116,6 +184,22 @@ func WithDemoMode() ClientOption {
 	}
 }
 
+// WithAPIBaseURL configures the client to send all data API and token requests to baseURL instead of
+// DefaultAPIBaseURL. This allows the client to be pointed at regional hosts, proxies or local stand-in servers.
+func WithAPIBaseURL(baseURL url.URL) ClientOption {
+	return func(c *Client) {
+		c.apiBaseURL = baseURL
+	}
+}
+
+// WithAccountBaseURL configures the client to generate user authorization URLs against baseURL instead of
+// DefaultAccountBaseURL.
+func WithAccountBaseURL(baseURL url.URL) ClientOption {
+	return func(c *Client) {
+		c.accountBaseURL = baseURL
+	}
+}
+
 // GetUserAuthRequestURL generates the URL that a user must access to grant this client's access to their Withings
 // data. The scope of access is determined by the scopes provided. After successful granting, the Withings API will
 // redirect the user to the redirectURL specified. This URL must be set to the same URL base as the value set for the
@@ -126,10 +210,9 @@ func WithDemoMode() ClientOption {
 func (c *Client) GetUserAuthRequestURL(scopes []string, state string) (authRequestURL *url.URL, expectedState string, err error) {
 
 	// Building base request.
-	authRequestURL, err = url.Parse(APIPathUserAuthorize)
+	authRequestURL, err = url.Parse(c.accountURL(PathUserAuthorize))
 	if err != nil {
-		// This must never fail. Panic here so tests fail hard and fast
-		panic(err)
+		return nil, "", fmt.Errorf("failed to build auth request url: %w", err)
 	}
 	query := authRequestURL.Query()
 
@@ -161,18 +244,54 @@ func (c *Client) GetUserAuthRequestURL(scopes []string, state string) (authReque
 	return authRequestURL, state, nil
 }
 
+// AccessTokenResponse is the response of the oauth2 service to token requests.
 type AccessTokenResponse struct {
 	Status      int64       `json:"status"`
+	APIError    string      `json:"error"`
 	AccessToken AccessToken `json:"body"`
 }
+
+// AccessToken is the token granting access to the data of a user.
 type AccessToken struct {
-	UserID       interface{} `json:"-"`
-	AccessToken  string      `json:"access_token"`
-	RefreshToken string      `json:"refresh_token"`
-	ExpiresIn    int64       `json:"expires_in"`
-	ExpiresAt    time.Time   `json:"expires_at"`
-	CSRFToken    string      `json:"csrf_token"`
-	TokenType    string      `json:"token_type"`
+	// The Withings ID of the user the token belongs to.
+	UserID       string    `json:"userid,omitempty"`
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresIn    int64     `json:"expires_in"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	CSRFToken    string    `json:"csrf_token"`
+	TokenType    string    `json:"token_type"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler. The API returns the user ID either as a string or a number, both of
+// which are stored as a string.
+func (t *AccessToken) UnmarshalJSON(data []byte) error {
+	type accessToken AccessToken
+	var v struct {
+		accessToken
+		UserID json.RawMessage `json:"userid"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	*t = AccessToken(v.accessToken)
+	switch {
+	case len(v.UserID) == 0 || string(v.UserID) == "null":
+		t.UserID = ""
+	case v.UserID[0] == '"':
+		if err := json.Unmarshal(v.UserID, &t.UserID); err != nil {
+			return fmt.Errorf("failed to parse userid: %w", err)
+		}
+	default:
+		var n json.Number
+		if err := json.Unmarshal(v.UserID, &n); err != nil {
+			return fmt.Errorf("failed to parse userid: %w", err)
+		}
+		t.UserID = n.String()
+	}
+
+	return nil
 }
 
 func (a *AccessTokenResponse) SetExpires(t time.Time) {
@@ -183,6 +302,12 @@ func (a *AccessTokenResponse) SetExpires(t time.Time) {
 // user visiting the URL provided by GetUserAuthenticationRequestURL and allowing access. The redirectURL provided
 // must match the URL provided during generation of the authCode.
 func (c *Client) GetUserAccessToken(authCode string) (*AccessTokenResponse, error) {
+	return c.GetUserAccessTokenContext(context.Background(), authCode)
+}
+
+// GetUserAccessTokenContext retrieves a new user access token using the AuthCode provided, see GetUserAccessToken. If
+// the API responds with a non zero status the response is returned along with an *APIError.
+func (c *Client) GetUserAccessTokenContext(ctx context.Context, authCode string) (*AccessTokenResponse, error) {
 
 	// Building required form data for the request.
 	formData := url.Values{}
@@ -193,40 +318,18 @@ func (c *Client) GetUserAccessToken(authCode string) (*AccessTokenResponse, erro
 	formData.Set("code", authCode)
 	formData.Set("redirect_uri", c.redirectURL.String())
 
-	fmt.Printf("code: %s\n", authCode)
-	fmt.Println(formData.Encode())
-
-	req, err := http.NewRequest(http.MethodPost, APIPathUserAccessToken, strings.NewReader(formData.Encode()))
-	if err != nil {
-		return nil, fmt.Errorf("failed to build request: %s", err)
-	}
-	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
-
-	requestTime := time.Now()
-	resp, err := c.HttpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %s", err)
-	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read body: %s", err)
-	}
-
-	switch resp.StatusCode {
-	case http.StatusOK:
-		var accessToken AccessTokenResponse
-		if err = json.Unmarshal(body, &accessToken); err != nil {
-			return nil, fmt.Errorf("failed to parse response: %s", err)
-		}
-		accessToken.SetExpires(requestTime)
-		return &accessToken, nil
-	default:
-		return nil, fmt.Errorf("failed with API error")
-	}
+	return c.requestToken(ctx, formData)
 }
 
+// RefreshAccessToken retrieves a new access token using the refresh token of the token provided. The refresh token
+// provided is invalidated by the API and must be replaced with the one returned.
 func (c *Client) RefreshAccessToken(token AccessToken) (*AccessTokenResponse, error) {
+	return c.RefreshAccessTokenContext(context.Background(), token)
+}
+
+// RefreshAccessTokenContext retrieves a new access token using the refresh token of the token provided, see
+// RefreshAccessToken. If the API responds with a non zero status the response is returned along with an *APIError.
+func (c *Client) RefreshAccessTokenContext(ctx context.Context, token AccessToken) (*AccessTokenResponse, error) {
 	// Building required form data for the request.
 	formData := url.Values{}
 	formData.Set("action", "requesttoken")
@@ -235,34 +338,28 @@ func (c *Client) RefreshAccessToken(token AccessToken) (*AccessTokenResponse, er
 	formData.Set("grant_type", "refresh_token")
 	formData.Set("refresh_token", token.RefreshToken)
 
-	req, err := http.NewRequest(http.MethodPost, APIPathUserAccessToken, strings.NewReader(formData.Encode()))
+	return c.requestToken(withUserID(ctx, token.UserID), formData)
+}
+
+// requestToken performs a request to the oauth2 service with the form data provided. The form data contains secrets
+// and must never be written anywhere.
+func (c *Client) requestToken(ctx context.Context, formData url.Values) (*AccessTokenResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.apiURL(PathOAuth2), strings.NewReader(formData.Encode()))
 	if err != nil {
-		return nil, fmt.Errorf("failed to build request: %s", err)
+		return nil, fmt.Errorf("failed to build request: %w", err)
 	}
 	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 
 	requestTime := time.Now()
-	resp, err := c.HttpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %s", err)
-	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
-	log.Println(string(body))
-	if err != nil {
-		return nil, fmt.Errorf("failed to read body: %s", err)
+	var accessToken AccessTokenResponse
+	if err = c.do(req, &accessToken); err != nil {
+		return nil, err
 	}
 
-	switch resp.StatusCode {
-	case http.StatusOK:
-		var accessToken AccessTokenResponse
-		if err = json.Unmarshal(body, &accessToken); err != nil {
-			return nil, fmt.Errorf("failed to parse response: %s", err)
-		}
-
-		accessToken.SetExpires(requestTime)
-		return &accessToken, nil
-	default:
-		return nil, fmt.Errorf("failed with API error")
+	if accessToken.Status != StatusOK {
+		return &accessToken, newAPIError(req, accessToken.Status, accessToken.APIError)
 	}
+
+	accessToken.SetExpires(requestTime)
+	return &accessToken, nil
 }
@@ -2,11 +2,16 @@ package withings_test
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/jrmycanady/withings"
+	"github.com/jrmycanady/withings/withingstest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"io"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"os"
 	"testing"
@@ -19,6 +24,9 @@ var client *withings.Client
 // demoToken contains the token generated for the demo user on init.
 var demoToken *withings.AccessToken
 
+// fakeServer contains the fake Withings API the tests run against when no test credentials are configured.
+var fakeServer *withingstest.Server
+
 // testingConfig contains the testing configuration loaded from the environmental variables.
 var testingConfig = struct {
 	ClientID     string
@@ -34,6 +42,22 @@ func loadTestConfigFromEnv() {
 	testingConfig.ClientID = os.Getenv("GO_WITHINGS_TEST_CLIENT_ID")
 	testingConfig.ClientSecret = os.Getenv("GO_WITHINGS_TEST_CLIENT_SECRET")
 	testingConfig.RedirectURL = os.Getenv("GO_WITHINGS_TEST_REDIRECT_URL")
+
+	// Without credentials the tests run offline against the fake Withings API seeded with a demo user.
+	if testingConfig.ClientID == "" {
+		fakeServer = withingstest.NewServer()
+		fakeServer.AddUser(withingstest.NewDemoUser("demo", time.Now()))
+		testingConfig.ClientID, testingConfig.ClientSecret = fakeServer.ClientCredentials()
+		testingConfig.RedirectURL = fakeServer.URL + "/callback"
+	}
+}
+
+// testClientOptions returns the options needed to point a client at the API being tested against.
+func testClientOptions(opts ...withings.ClientOption) []withings.ClientOption {
+	if fakeServer == nil {
+		return opts
+	}
+	return append(fakeServer.ClientOptions(), opts...)
 }
 
 func TestClient_SetHTTPClientTimeout_Option(t *testing.T) {
@@ -65,6 +89,37 @@ func TestClient_WithSkipSSLVerify_Option(t *testing.T) {
 	}
 }
 
+func TestClient_WithAPIBaseURL_Option(t *testing.T) {
+	var requestedPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestedPath = r.URL.Path
+		w.Write([]byte(`{"status":0,"body":{}}`))
+	}))
+	defer srv.Close()
+
+	baseURL, err := url.Parse(srv.URL + "/proxy/")
+	require.Nil(t, err)
+
+	c := withings.NewClient(testingConfig.ClientID, testingConfig.ClientSecret, url.URL{}, withings.WithAPIBaseURL(*baseURL))
+	assert.Equal(t, *baseURL, c.APIBaseURL())
+
+	_, err = c.GetMeasure(context.Background(), withings.AccessToken{}, withings.GetMeasureParam{})
+	require.Nil(t, err)
+	assert.Equal(t, "/proxy"+withings.PathMeasure, requestedPath)
+}
+
+func TestClient_WithAccountBaseURL_Option(t *testing.T) {
+	baseURL, err := url.Parse("http://127.0.0.1:8080")
+	require.Nil(t, err)
+
+	c := withings.NewClient(testingConfig.ClientID, testingConfig.ClientSecret, url.URL{}, withings.WithAccountBaseURL(*baseURL))
+
+	authURL, _, err := c.GetUserAuthRequestURL([]string{}, "")
+	require.Nil(t, err)
+	assert.Equal(t, "127.0.0.1:8080", authURL.Host)
+	assert.Equal(t, withings.PathUserAuthorize, authURL.Path)
+}
+
 func TestClient_GetAuthenticationRequestURL(t *testing.T) {
 	t.Parallel()
 
@@ -127,7 +182,7 @@ func getDemoClient() (*withings.Client, *withings.AccessToken, error) {
 		return nil, nil, fmt.Errorf("failed to  build url: %s", err)
 	}
 
-	c := withings.NewClient(testingConfig.ClientID, testingConfig.ClientSecret, *redirectURL, withings.SetHTTPClientTimeout(10*time.Second), withings.WithDemoMode())
+	c := withings.NewClient(testingConfig.ClientID, testingConfig.ClientSecret, *redirectURL, testClientOptions(withings.SetHTTPClientTimeout(10*time.Second), withings.WithDemoMode())...)
 
 	accessToken, err := c.GetDemoAccessToken()
 	if err != nil {
@@ -400,6 +455,29 @@ func TestClient_ListNotification(t *testing.T) {
 
 }
 
+func TestListNotificationParam_UpdateQuery(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		param withings.ListNotificationParam
+		appli string
+	}{
+		"Category":      {param: withings.ListNotificationParam{Appli: 44}, appli: "44"},
+		"Zero category": {param: withings.ListNotificationParam{}, appli: "0"},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			q := test.param.UpdateQuery(url.Values{})
+			assert.Equal(t, withings.APIActionNotificationList, q.Get("action"))
+			assert.Equal(t, []string{test.appli}, q["appli"])
+		})
+	}
+}
+
 func TestClient_GetMeasureWithRefreshedToken(t *testing.T) {
 	t.Parallel()
 
@@ -473,3 +551,71 @@ func TestAuthorizedUser_GetMeasure(t *testing.T) {
 	}
 
 }
+
+func TestClient_GetUserAccessTokenContext(t *testing.T) {
+	srv := withingstest.NewServer()
+	defer srv.Close()
+	srv.AddUser(withingstest.NewDemoUser("1234", time.Now()))
+
+	redirectURL, err := url.Parse(srv.URL + "/callback")
+	require.Nil(t, err)
+	c := srv.NewClient(*redirectURL)
+	ctx := context.Background()
+
+	// Capturing stdout to verify no secrets are written.
+	stdout := os.Stdout
+	r, w, err := os.Pipe()
+	require.Nil(t, err)
+	os.Stdout = w
+
+	resp, err := c.GetUserAccessTokenContext(ctx, srv.IssueCode("1234", redirectURL.String()))
+	require.Nil(t, err)
+	refreshed, refreshErr := c.RefreshAccessTokenContext(ctx, resp.AccessToken)
+
+	os.Stdout = stdout
+	require.Nil(t, w.Close())
+	output, err := io.ReadAll(r)
+	require.Nil(t, err)
+
+	require.Nil(t, refreshErr)
+	assert.Empty(t, string(output))
+	assert.Equal(t, "1234", resp.AccessToken.UserID)
+	assert.Equal(t, "1234", refreshed.AccessToken.UserID)
+	assert.True(t, resp.AccessToken.ExpiresAt.After(time.Now()))
+
+	// The refresh token has been used and the API responds with a non zero status.
+	reused, err := c.RefreshAccessTokenContext(ctx, resp.AccessToken)
+	require.NotNil(t, err)
+	assert.True(t, errors.Is(err, withings.ErrInvalidParams))
+	assert.Equal(t, withings.StatusInvalidParams, reused.Status)
+
+	_, err = c.GetUserAccessTokenContext(ctx, "invalid")
+	var apiErr *withings.APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "requesttoken", apiErr.Action)
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = c.RefreshAccessTokenContext(canceled, refreshed.AccessToken)
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func TestAccessToken_UnmarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		body   string
+		userID string
+	}{
+		"String":  {body: `{"userid":"1234","access_token":"a"}`, userID: "1234"},
+		"Number":  {body: `{"userid":1234,"access_token":"a"}`, userID: "1234"},
+		"Missing": {body: `{"access_token":"a"}`, userID: ""},
+	}
+
+	for name, test := range tests {
+		var token withings.AccessToken
+		require.Nil(t, json.Unmarshal([]byte(test.body), &token), name)
+		assert.Equal(t, test.userID, token.UserID, name)
+		assert.Equal(t, "a", token.AccessToken, name)
+	}
+}
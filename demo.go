@@ -81,8 +81,6 @@ func (c *Client) GetDemoAccessToken() (*AccessTokenResponse, error) {
 		return nil, fmt.Errorf("failed to get access token with code: %s", err)
 	}
 
-	fmt.Println(acessToken)
-
 	// The request was accepted so issue the request with the proper form data.
 	return acessToken, nil
 }
@@ -0,0 +1,170 @@
+package withings
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Statuses returned by the Withings API in the status field of a response. See the Withings API reference for the
+// complete catalog, the remaining statuses fall within the ranges classified by ClassifyStatus.
+const (
+	StatusOK                   int64 = 0
+	StatusAuthenticationFailed int64 = 401
+	StatusInvalidParams        int64 = 503
+	StatusTimeout              int64 = 522
+	StatusTooManyRequests      int64 = 601
+	StatusNotImplemented       int64 = 2553
+	StatusWrongAction          int64 = 2554
+	StatusUnknownError         int64 = 2555
+	StatusServiceNotDefined    int64 = 2556
+)
+
+// Sentinel errors an *APIError matches with errors.Is based on its status.
+var (
+	// ErrInvalidToken denotes the access token was missing, invalid or expired. A new token should be obtained.
+	ErrInvalidToken = errors.New("invalid access token")
+
+	// ErrInvalidParams denotes the parameters of the request were rejected. Retrying will not succeed.
+	ErrInvalidParams = errors.New("invalid params")
+
+	// ErrUnauthorized denotes the client is not allowed to perform the request.
+	ErrUnauthorized = errors.New("unauthorized")
+
+	// ErrRateLimited denotes the client exceeded its request quota. The request should be retried after backing off.
+	ErrRateLimited = errors.New("too many requests")
+
+	// ErrNotImplemented denotes the service or action requested does not exist.
+	ErrNotImplemented = errors.New("not implemented")
+
+	// ErrTimeout denotes the API timed out handling the request. The request may be retried.
+	ErrTimeout = errors.New("timeout")
+
+	// ErrRetryable is matched by every error that is temporary and may succeed if retried. This includes
+	// ErrRateLimited and ErrTimeout.
+	ErrRetryable = errors.New("temporary failure")
+)
+
+// APIError is an error returned by the Withings API. It is returned whenever the API responds with a non zero status
+// or with an unexpected HTTP status code. The error matches the sentinel errors of this package with errors.Is based
+// on the status.
+type APIError struct {
+	// The Withings status of the response. It is zero if the API responded with an unexpected HTTP status instead.
+	Status int64
+
+	// The HTTP status code of the response.
+	HTTPStatus int
+
+	// The error text returned by the API.
+	Message string
+
+	// The action that was requested.
+	Action string
+
+	// The URL of the service that was called, without any query.
+	Endpoint string
+}
+
+// newAPIError builds an APIError for the request provided.
+func newAPIError(req *http.Request, status int64, message string) *APIError {
+	return &APIError{
+		Status:     status,
+		HTTPStatus: http.StatusOK,
+		Message:    message,
+		Action:     actionOf(req),
+		Endpoint:   endpointOf(req.URL),
+	}
+}
+
+// endpointOf returns u without its query or fragment.
+func endpointOf(u *url.URL) string {
+	e := *u
+	e.RawQuery = ""
+	e.Fragment = ""
+	return e.String()
+}
+
+// Error implements the error interface.
+func (e *APIError) Error() string {
+	if e.Status == StatusOK && e.HTTPStatus != http.StatusOK {
+		return fmt.Sprintf("api returned http status %d for action %s on %s", e.HTTPStatus, e.Action, e.Endpoint)
+	}
+	return fmt.Sprintf("api returned an error: %s (status %d for action %s on %s)", e.Message, e.Status, e.Action, e.Endpoint)
+}
+
+// Is reports whether the error matches target. An APIError matches the sentinel error its status is classified as
+// and ErrRetryable if the error is temporary.
+func (e *APIError) Is(target error) bool {
+	if target == ErrRetryable {
+		return e.Temporary()
+	}
+
+	kind := e.kind()
+	return kind != nil && kind == target
+}
+
+// Temporary reports whether the request may succeed if retried.
+func (e *APIError) Temporary() bool {
+	switch e.kind() {
+	case ErrRateLimited, ErrTimeout, ErrRetryable:
+		return true
+	default:
+		return false
+	}
+}
+
+// kind returns the sentinel error the APIError is classified as or nil if it is not classified.
+func (e *APIError) kind() error {
+	if e.Status == StatusOK {
+		return classifyHTTPStatus(e.HTTPStatus)
+	}
+	return ClassifyStatus(e.Status)
+}
+
+// ClassifyStatus returns the sentinel error the Withings status provided is classified as. It returns nil for
+// StatusOK and for statuses outside the documented ranges.
+func ClassifyStatus(status int64) error {
+	switch {
+	case status == StatusOK:
+		return nil
+	case status >= 100 && status <= 102, status == 200, status == StatusAuthenticationFailed:
+		return ErrInvalidToken
+	case status == 214, status == 277:
+		return ErrUnauthorized
+	case status == StatusTimeout:
+		return ErrTimeout
+	case status == StatusTooManyRequests:
+		return ErrRateLimited
+	case status == StatusNotImplemented, status == StatusWrongAction, status == StatusServiceNotDefined:
+		return ErrNotImplemented
+	case status == StatusUnknownError:
+		return ErrRetryable
+	case status >= 201 && status <= 399, status == 402, status >= 500 && status <= 599, status >= 3017 && status <= 3019:
+		return ErrInvalidParams
+	default:
+		return nil
+	}
+}
+
+// classifyHTTPStatus returns the sentinel error an unexpected HTTP status code is classified as.
+func classifyHTTPStatus(code int) error {
+	switch {
+	case code == http.StatusUnauthorized:
+		return ErrInvalidToken
+	case code == http.StatusForbidden:
+		return ErrUnauthorized
+	case code == http.StatusNotFound, code == http.StatusNotImplemented:
+		return ErrNotImplemented
+	case code == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case code == http.StatusRequestTimeout, code == http.StatusGatewayTimeout:
+		return ErrTimeout
+	case code >= 500:
+		return ErrRetryable
+	case code >= 400:
+		return ErrInvalidParams
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,100 @@
+package withings_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jrmycanady/withings"
+	"github.com/jrmycanady/withings/withingstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIError_Is(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		err       *withings.APIError
+		target    error
+		retryable bool
+	}{
+		"Authentication failed": {
+			err:    &withings.APIError{Status: withings.StatusAuthenticationFailed},
+			target: withings.ErrInvalidToken,
+		},
+		"Invalid params": {
+			err:    &withings.APIError{Status: 293},
+			target: withings.ErrInvalidParams,
+		},
+		"Unauthorized": {
+			err:    &withings.APIError{Status: 214},
+			target: withings.ErrUnauthorized,
+		},
+		"Too many requests": {
+			err:       &withings.APIError{Status: withings.StatusTooManyRequests},
+			target:    withings.ErrRateLimited,
+			retryable: true,
+		},
+		"Timeout": {
+			err:       &withings.APIError{Status: withings.StatusTimeout},
+			target:    withings.ErrTimeout,
+			retryable: true,
+		},
+		"Wrong action": {
+			err:    &withings.APIError{Status: withings.StatusWrongAction},
+			target: withings.ErrNotImplemented,
+		},
+		"Unknown error": {
+			err:       &withings.APIError{Status: withings.StatusUnknownError},
+			target:    withings.ErrRetryable,
+			retryable: true,
+		},
+		"HTTP bad gateway": {
+			err:       &withings.APIError{HTTPStatus: http.StatusBadGateway},
+			target:    withings.ErrRetryable,
+			retryable: true,
+		},
+		"HTTP too many requests": {
+			err:       &withings.APIError{HTTPStatus: http.StatusTooManyRequests},
+			target:    withings.ErrRateLimited,
+			retryable: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var err error = test.err
+			assert.True(t, errors.Is(err, test.target))
+			assert.Equal(t, test.retryable, errors.Is(err, withings.ErrRetryable))
+			assert.Equal(t, test.retryable, test.err.Temporary())
+		})
+	}
+}
+
+func TestAPIError_FromEndpoint(t *testing.T) {
+	t.Parallel()
+
+	srv := withingstest.NewServer()
+	defer srv.Close()
+	srv.AddUser(withingstest.NewDemoUser("1", time.Now()))
+	c := srv.NewClient(url.URL{})
+
+	srv.Fail(withingstest.Failure{Action: withings.APIActionGetSleepSummary, Status: withings.StatusTooManyRequests, Error: "Too Many Requests"})
+	_, err := c.GetSleepSummary(context.Background(), srv.IssueToken("1"), withings.GetSleepSummaryParam{})
+	require.NotNil(t, err)
+
+	var apiErr *withings.APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, withings.StatusTooManyRequests, apiErr.Status)
+	assert.Equal(t, "Too Many Requests", apiErr.Message)
+	assert.Equal(t, withings.APIActionGetSleepSummary, apiErr.Action)
+	assert.Equal(t, srv.URL+withings.PathSleepV2, apiErr.Endpoint)
+	assert.True(t, errors.Is(err, withings.ErrRateLimited))
+
+	_, err = c.GetMeasure(context.Background(), withings.AccessToken{AccessToken: "invalid"}, withings.GetMeasureParam{})
+	assert.True(t, errors.Is(err, withings.ErrInvalidToken))
+}
@@ -0,0 +1,20 @@
+package withings
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Signature exposes signature to the tests.
+var Signature = signature
+
+// SignedRequest exposes signedRequest to the tests.
+func (c *Client) SignedRequest(ctx context.Context, path string, action string, formData url.Values) (*http.Request, error) {
+	return c.signedRequest(ctx, path, action, formData)
+}
+
+// Sign exposes sign to the tests.
+func (c *Client) Sign(action string, value string) string {
+	return c.sign(action, value)
+}
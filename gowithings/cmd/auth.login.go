@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/jrmycanady/withings"
+	"github.com/spf13/cobra"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var authLoginCmdVars = struct {
+	scopes  string
+	timeout time.Duration
+}{}
+
+var authLoginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "Authorizes a user interactively by serving the OAuth callback on the redirect-url and outputs the token.",
+	Long: `Authorizes a user interactively. A temporary HTTP server is started on the host and port of the redirect-url,
+which must be a loopback http URL registered for the client, and the authorization URL is printed. Once the user
+grants access in their browser the callback is received and the resulting token is output as JSON.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		rURL, err := url.Parse(authGenerateRequestURLCmdVars.redirectURL)
+		if err != nil {
+			log.Fatalf("failed to parse redirect-url: %s", err)
+		}
+
+		opts := []withings.ClientOption{}
+		if ConfigOptions.Demo {
+			opts = append(opts, withings.WithDemoMode())
+		}
+		if ConfigOptions.SkipCertificateVerification {
+			opts = append(opts, withings.WithSkipSSLVerify())
+		}
+		c := withings.NewClient(ConfigOptions.ClientID, ConfigOptions.ClientSecret, *rURL, opts...)
+
+		scopes := strings.Split(authLoginCmdVars.scopes, ",")
+		for i := range scopes {
+			scopes[i] = strings.TrimSpace(scopes[i])
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), authLoginCmdVars.timeout)
+		defer cancel()
+
+		token, err := c.AuthorizeLoopback(ctx, scopes, func(authURL *url.URL) error {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Open the following URL in your browser to grant access:\n\n%s\n\n", authURL.String())
+			return nil
+		})
+		if err != nil {
+			log.Fatalf("failed to authorize: %s", err)
+		}
+
+		out, err := json.MarshalIndent(token, "", " ")
+		if err != nil {
+			log.Fatalf("failed to marshal token: %s", err)
+		}
+
+		fmt.Fprintln(cmd.OutOrStdout(), string(out))
+	},
+}
+
+func init() {
+	authLoginCmd.Flags().StringVar(&authLoginCmdVars.scopes, "scopes", "user.activity,user.metrics", "Comma separated list of scopes that will be requested for access.")
+	authLoginCmd.Flags().DurationVar(&authLoginCmdVars.timeout, "timeout", 5*time.Minute, "How long to wait for the user to grant access.")
+
+	authCmd.AddCommand(authLoginCmd)
+}
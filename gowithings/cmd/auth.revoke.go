@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"github.com/jrmycanady/withings"
+	"github.com/spf13/cobra"
+	"log"
+	"net/url"
+	"time"
+)
+
+var authRevokeCmdVars = struct {
+	userID  string
+	timeout time.Duration
+}{}
+
+var authRevokeCmd = &cobra.Command{
+	Use:   "revoke",
+	Short: "Revokes the access of the client to the data of a user.",
+	Long: `Revokes the access of the client to the data of a user, unlinking the user from the application. Every token
+issued to the client for the user is invalidated. Notification subscriptions of the user are not revoked.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		opts := []withings.ClientOption{}
+		if ConfigOptions.Demo {
+			opts = append(opts, withings.WithDemoMode())
+		}
+		if ConfigOptions.SkipCertificateVerification {
+			opts = append(opts, withings.WithSkipSSLVerify())
+		}
+		c := withings.NewClient(ConfigOptions.ClientID, ConfigOptions.ClientSecret, url.URL{}, opts...)
+
+		ctx, cancel := context.WithTimeout(context.Background(), authRevokeCmdVars.timeout)
+		defer cancel()
+
+		if _, err := c.RevokeUser(ctx, authRevokeCmdVars.userID); err != nil {
+			log.Fatalf("failed to revoke access: %s", err)
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Revoked access to user %s\n", authRevokeCmdVars.userID)
+	},
+}
+
+func init() {
+	authRevokeCmd.Flags().StringVar(&authRevokeCmdVars.userID, "user-id", "", "The Withings user ID of the user to revoke access to.")
+	authRevokeCmd.MarkFlagRequired("user-id")
+	authRevokeCmd.Flags().DurationVar(&authRevokeCmdVars.timeout, "timeout", time.Minute, "How long to wait for the revocation to complete.")
+
+	authCmd.AddCommand(authRevokeCmd)
+}
@@ -0,0 +1,126 @@
+package withings
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+// Logger is a structured logger the client emits events to. The args are alternating keys and values. The method
+// matches that of *slog.Logger so it may be used directly, other logging packages are easily adapted.
+type Logger interface {
+	DebugContext(ctx context.Context, msg string, args ...interface{})
+}
+
+// Keys of the values of the events emitted to the Logger.
+const (
+	LogKeyAction     = "action"
+	LogKeyEndpoint   = "endpoint"
+	LogKeyDuration   = "duration"
+	LogKeyHTTPStatus = "http_status"
+	LogKeyStatus     = "status"
+	LogKeyAttempt    = "attempt"
+	LogKeyRetries    = "retries"
+	LogKeyUserID     = "user_id"
+	LogKeyWait       = "wait"
+	LogKeyError      = "error"
+)
+
+// WithLogger configures the client to emit an event at debug level for every request attempt to the logger
+// provided, see LoggingMiddleware. Events never contain access tokens, refresh tokens, codes or the client secret,
+// any that would appear in a value are redacted.
+func WithLogger(logger Logger) ClientOption {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// userIDKey is the context key of the Withings user ID a request is made for.
+type userIDKey struct{}
+
+// withUserID returns a copy of ctx carrying the user ID provided. An empty user ID leaves ctx unchanged.
+func withUserID(ctx context.Context, userID string) context.Context {
+	if userID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, userIDKey{}, userID)
+}
+
+// userIDFrom returns the user ID carried by ctx, if any.
+func userIDFrom(ctx context.Context) string {
+	userID, _ := ctx.Value(userIDKey{}).(string)
+	return userID
+}
+
+// authorize adds the access token to the request and records the user the token belongs to in its context.
+func authorize(req *http.Request, token AccessToken) *http.Request {
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token.AccessToken))
+	return req.WithContext(withUserID(req.Context(), token.UserID))
+}
+
+// redactions match the secrets that may appear in a logged value along with the replacement of each.
+var redactions = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{
+		pattern:     regexp.MustCompile(`(?i)\b(access_token|refresh_token|client_secret|code|signature)=[^&\s"']+`),
+		replacement: "$1=REDACTED",
+	},
+	{
+		pattern:     regexp.MustCompile(`(?i)"(access_token|refresh_token|client_secret|code|signature)"\s*:\s*"[^"]*"`),
+		replacement: `"$1":"REDACTED"`,
+	},
+	{
+		pattern:     regexp.MustCompile(`(?i)\bbearer\s+[^\s"']+`),
+		replacement: "Bearer REDACTED",
+	},
+}
+
+// redact replaces every secret in s.
+func redact(s string) string {
+	for _, r := range redactions {
+		s = r.pattern.ReplaceAllString(s, r.replacement)
+	}
+	return s
+}
+
+// LoggingMiddleware returns a middleware emitting an event at debug level for every attempt of a call to the logger
+// provided. WithLogger installs this middleware inside the retry middleware so every attempt is logged.
+func LoggingMiddleware(logger Logger) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(call *Call) (*CallResponse, error) {
+			waitBefore := call.RateLimitWait
+			start := time.Now()
+
+			resp, err := next(call)
+			wait := call.RateLimitWait - waitBefore
+
+			args := []interface{}{
+				LogKeyAction, call.Action,
+				LogKeyEndpoint, redact(call.Endpoint),
+				LogKeyDuration, time.Since(start) - wait,
+			}
+			// A transport failure has no statuses, only the error is logged.
+			if resp != nil {
+				args = append(args, LogKeyHTTPStatus, resp.HTTPStatus, LogKeyStatus, resp.Status)
+			}
+			args = append(args, LogKeyAttempt, call.Attempt, LogKeyRetries, call.Attempt-1)
+			if call.UserID != "" {
+				args = append(args, LogKeyUserID, call.UserID)
+			}
+			if wait > 0 {
+				args = append(args, LogKeyWait, wait)
+			}
+			if err != nil {
+				args = append(args, LogKeyError, redact(err.Error()))
+			}
+
+			logger.DebugContext(call.Context(), "withings request", args...)
+
+			return resp, err
+		}
+	}
+}
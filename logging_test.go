@@ -0,0 +1,120 @@
+package withings_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jrmycanady/withings"
+	"github.com/jrmycanady/withings/withingstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// logEvent is an event received by testLogger.
+type logEvent struct {
+	msg  string
+	args map[string]interface{}
+	raw  string
+}
+
+// testLogger records the events it receives.
+type testLogger struct {
+	mu     sync.Mutex
+	events []logEvent
+}
+
+func (l *testLogger) DebugContext(_ context.Context, msg string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e := logEvent{msg: msg, args: make(map[string]interface{}), raw: fmt.Sprint(args...)}
+	for i := 0; i+1 < len(args); i += 2 {
+		e.args[args[i].(string)] = args[i+1]
+	}
+	l.events = append(l.events, e)
+}
+
+// roundTripFunc is an http.RoundTripper implemented by a function.
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestClient_WithLogger(t *testing.T) {
+	t.Parallel()
+
+	srv := withingstest.NewServer()
+	defer srv.Close()
+	srv.AddUser(withingstest.NewDemoUser("1234", time.Now()))
+	logger := &testLogger{}
+	c := srv.NewClient(url.URL{}, withings.WithLogger(logger), withings.WithRetryPolicy(withings.RetryPolicy{
+		MaxAttempts:    2,
+		InitialBackoff: time.Millisecond,
+	}))
+	ctx := context.Background()
+	clientID, clientSecret := srv.ClientCredentials()
+
+	token := srv.IssueToken("1234")
+	token.ExpiresAt = time.Now().Add(-time.Minute)
+	u := c.NewAuthorizedUser(token)
+
+	srv.Fail(withingstest.Failure{Path: withings.PathMeasure, Status: withings.StatusTooManyRequests})
+	_, err := u.API().GetMeasure(ctx, withings.GetMeasureParam{})
+	require.Nil(t, err)
+	refreshed := u.Token()
+
+	require.Len(t, logger.events, 3)
+
+	refresh := logger.events[0]
+	assert.Equal(t, "requesttoken", refresh.args[withings.LogKeyAction])
+	assert.Equal(t, "1234", refresh.args[withings.LogKeyUserID])
+	assert.Equal(t, http.StatusOK, refresh.args[withings.LogKeyHTTPStatus])
+
+	limited := logger.events[1]
+	assert.Equal(t, withings.APIActionGetMeasure, limited.args[withings.LogKeyAction])
+	assert.Equal(t, withings.StatusTooManyRequests, limited.args[withings.LogKeyStatus])
+	assert.Equal(t, 1, limited.args[withings.LogKeyAttempt])
+	assert.Contains(t, limited.args[withings.LogKeyError], "status 601")
+
+	retried := logger.events[2]
+	assert.Equal(t, withings.StatusOK, retried.args[withings.LogKeyStatus])
+	assert.Equal(t, 1, retried.args[withings.LogKeyRetries])
+	assert.Equal(t, "1234", retried.args[withings.LogKeyUserID])
+	assert.Equal(t, srv.URL+withings.PathMeasure, retried.args[withings.LogKeyEndpoint])
+	assert.IsType(t, time.Duration(0), retried.args[withings.LogKeyDuration])
+
+	for _, e := range logger.events {
+		for _, secret := range []string{token.AccessToken, token.RefreshToken, refreshed.AccessToken, refreshed.RefreshToken, clientID, clientSecret} {
+			assert.NotContains(t, e.raw, secret)
+		}
+	}
+}
+
+func TestClient_WithLogger_Redacts(t *testing.T) {
+	t.Parallel()
+
+	logger := &testLogger{}
+	c := withings.NewClient("id", "secret", url.URL{}, withings.WithLogger(logger))
+	c.HttpClient.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, errors.New(`refused refresh_token=rt-secret&code=code-secret Authorization: Bearer at-secret {"access_token":"json-secret"}`)
+	})
+
+	_, err := c.GetMeasure(context.Background(), withings.AccessToken{AccessToken: "at-secret", UserID: "1"}, withings.GetMeasureParam{})
+	require.NotNil(t, err)
+
+	require.Len(t, logger.events, 1)
+	e := logger.events[0]
+	assert.NotContains(t, e.args, withings.LogKeyHTTPStatus)
+	assert.NotContains(t, e.args, withings.LogKeyStatus)
+	for _, secret := range []string{"rt-secret", "code-secret", "at-secret", "json-secret"} {
+		assert.NotContains(t, e.raw, secret)
+	}
+	assert.Contains(t, e.args[withings.LogKeyError], "refresh_token=REDACTED")
+}
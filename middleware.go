@@ -0,0 +1,97 @@
+package withings
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Call is a single API call passing through the middleware chain of a client.
+type Call struct {
+	// The HTTP request of the call. Middlewares may modify its headers or replace it with a request derived from it,
+	// for example to add values to its context.
+	Request *http.Request
+
+	// The action requested, such as getmeas or requesttoken.
+	Action string
+
+	// The URL of the service that is called, without any query.
+	Endpoint string
+
+	// The Withings user ID of the token the call is made with. It is empty if the user is not known.
+	UserID string
+
+	// The attempt the call is on, starting at one. It is incremented by the retry middleware.
+	Attempt int
+
+	// The total time the attempts of the call waited on the client side rate limiter.
+	RateLimitWait time.Duration
+}
+
+// Context returns the context of the request of the call.
+func (c *Call) Context() context.Context {
+	return c.Request.Context()
+}
+
+// Params returns the combined query and form parameters of the request of the call.
+func (c *Call) Params() url.Values {
+	params := c.Request.URL.Query()
+	if form := formOf(c.Request); form != nil {
+		for k, v := range form {
+			params[k] = append(params[k], v...)
+		}
+	}
+	return params
+}
+
+// CallResponse is the response of the API to a Call.
+type CallResponse struct {
+	// The HTTP status code of the response.
+	HTTPStatus int
+
+	// The Withings status decoded from the body of the response.
+	Status int64
+
+	// The body of the response.
+	Body []byte
+}
+
+// RoundTripFunc performs an API call. The response is non nil whenever the API responded, even if an error is
+// returned. The error is an *APIError if the API responded with a non zero status or unexpected HTTP status.
+type RoundTripFunc func(call *Call) (*CallResponse, error)
+
+// Middleware wraps the RoundTripFunc performing API calls to intercept every call of a client. A middleware may
+// inspect or modify the call before passing it on to next, inspect the response or error returned by next, or not
+// call next at all and return a response of its own.
+type Middleware func(next RoundTripFunc) RoundTripFunc
+
+// WithMiddleware configures the client to pass every API call through the middlewares provided. The first
+// middleware is the outermost. Middlewares added by this option wrap the retry, logging and rate limiting of the
+// client, so they see each call once no matter how many attempts it takes. To intercept every attempt instead,
+// configure retrying with RetryMiddleware after the middleware.
+func WithMiddleware(middlewares ...Middleware) ClientOption {
+	return func(c *Client) {
+		c.middlewares = append(c.middlewares, middlewares...)
+	}
+}
+
+// roundTripper returns the RoundTripFunc of the client, consisting of the configured middlewares wrapping the
+// built-in behaviors and sending the request. The order, from outermost to innermost, is the middlewares added with
+// WithMiddleware, retrying, logging and rate limiting.
+func (c *Client) roundTripper() RoundTripFunc {
+	rt := RoundTripFunc(c.send)
+	if c.rateLimiter != nil {
+		rt = c.rateLimiter.middleware()(rt)
+	}
+	if c.logger != nil {
+		rt = LoggingMiddleware(c.logger)(rt)
+	}
+	if c.retryPolicy.MaxAttempts > 1 {
+		rt = RetryMiddleware(c.retryPolicy)(rt)
+	}
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		rt = c.middlewares[i](rt)
+	}
+	return rt
+}
@@ -0,0 +1,154 @@
+package withings_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jrmycanady/withings"
+	"github.com/jrmycanady/withings/withingstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_WithMiddleware(t *testing.T) {
+	t.Parallel()
+
+	srv := withingstest.NewServer()
+	defer srv.Close()
+	srv.AddUser(withingstest.NewDemoUser("1234", time.Now()))
+	ctx := context.Background()
+	token := srv.IssueToken("1234")
+
+	t.Run("Inspects calls in order", func(t *testing.T) {
+		var mu sync.Mutex
+		var trace []string
+		record := func(name string) withings.Middleware {
+			return func(next withings.RoundTripFunc) withings.RoundTripFunc {
+				return func(call *withings.Call) (*withings.CallResponse, error) {
+					resp, err := next(call)
+
+					mu.Lock()
+					defer mu.Unlock()
+					trace = append(trace, name, call.Action, call.UserID, call.Endpoint)
+					if resp != nil {
+						assert.Equal(t, withings.StatusOK, resp.Status)
+						assert.NotEmpty(t, resp.Body)
+					}
+					return resp, err
+				}
+			}
+		}
+
+		c := srv.NewClient(url.URL{}, withings.WithMiddleware(record("outer"), record("inner")))
+		_, err := c.GetMeasure(ctx, token, withings.GetMeasureParam{})
+		require.Nil(t, err)
+
+		endpoint := srv.URL + withings.PathMeasure
+		assert.Equal(t, []string{
+			"inner", withings.APIActionGetMeasure, "1234", endpoint,
+			"outer", withings.APIActionGetMeasure, "1234", endpoint,
+		}, trace)
+	})
+
+	t.Run("Short circuits", func(t *testing.T) {
+		var mu sync.Mutex
+		cache := make(map[string]*withings.CallResponse)
+		cacheMiddleware := func(next withings.RoundTripFunc) withings.RoundTripFunc {
+			return func(call *withings.Call) (*withings.CallResponse, error) {
+				key := call.Action + call.Params().Encode()
+
+				mu.Lock()
+				cached, ok := cache[key]
+				mu.Unlock()
+				if ok {
+					return cached, nil
+				}
+
+				resp, err := next(call)
+				if err == nil {
+					mu.Lock()
+					cache[key] = resp
+					mu.Unlock()
+				}
+				return resp, err
+			}
+		}
+
+		c := srv.NewClient(url.URL{}, withings.WithMiddleware(cacheMiddleware))
+		before := srv.RequestCount(withings.PathMeasureV2, withings.APIActionGetActivity)
+
+		first, err := c.GetActivity(ctx, token, withings.GetActivityParam{})
+		require.Nil(t, err)
+		second, err := c.GetActivity(ctx, token, withings.GetActivityParam{})
+		require.Nil(t, err)
+
+		assert.Equal(t, first, second)
+		assert.Equal(t, 1, srv.RequestCount(withings.PathMeasureV2, withings.APIActionGetActivity)-before)
+	})
+
+	t.Run("Retries injected faults", func(t *testing.T) {
+		var attempts []int
+		faults := 2
+		inject := func(next withings.RoundTripFunc) withings.RoundTripFunc {
+			return func(call *withings.Call) (*withings.CallResponse, error) {
+				attempts = append(attempts, call.Attempt)
+				if faults > 0 {
+					faults--
+					return &withings.CallResponse{HTTPStatus: 200, Status: withings.StatusTooManyRequests}, &withings.APIError{
+						Status:     withings.StatusTooManyRequests,
+						HTTPStatus: 200,
+						Action:     call.Action,
+						Endpoint:   call.Endpoint,
+					}
+				}
+				return next(call)
+			}
+		}
+
+		policy := withings.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+		c := srv.NewClient(url.URL{}, withings.WithMiddleware(withings.RetryMiddleware(policy), inject))
+
+		resp, err := c.GetSleepSummary(ctx, token, withings.GetSleepSummaryParam{})
+		require.Nil(t, err)
+		assert.NotEmpty(t, resp.Body.Series)
+		assert.Equal(t, []int{1, 2, 3}, attempts)
+
+		faults = 1
+		attempts = nil
+		c = srv.NewClient(url.URL{}, withings.WithMiddleware(inject))
+		_, err = c.GetSleepSummary(ctx, token, withings.GetSleepSummaryParam{})
+		assert.True(t, errors.Is(err, withings.ErrRateLimited))
+		assert.Equal(t, []int{1}, attempts)
+	})
+
+	t.Run("Returns errors of middlewares with a response", func(t *testing.T) {
+		errAudit := errors.New("audit failed")
+		audit := func(next withings.RoundTripFunc) withings.RoundTripFunc {
+			return func(call *withings.Call) (*withings.CallResponse, error) {
+				resp, err := next(call)
+				if err != nil {
+					return resp, err
+				}
+				return resp, errAudit
+			}
+		}
+
+		c := srv.NewClient(url.URL{}, withings.WithMiddleware(audit))
+		_, err := c.GetMeasure(ctx, token, withings.GetMeasureParam{})
+		assert.True(t, errors.Is(err, errAudit))
+	})
+
+	t.Run("Logging as middleware", func(t *testing.T) {
+		logger := &testLogger{}
+		c := srv.NewClient(url.URL{}, withings.WithMiddleware(withings.LoggingMiddleware(logger)))
+
+		_, err := c.GetWorkout(ctx, token, withings.GetWorkoutParam{})
+		require.Nil(t, err)
+		require.Len(t, logger.events, 1)
+		assert.Equal(t, withings.APIActionGetWorkout, logger.events[0].args[withings.LogKeyAction])
+	})
+}
@@ -2,9 +2,7 @@ package withings
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -50,36 +48,26 @@ func (p *SubscribeToNotificationsParam) UpdateQuery(q url.Values, clientID strin
 func (c *Client) SubscribeToNotifications(ctx context.Context, token AccessToken, param SubscribeToNotificationsParam) (*SubscribeToNotificationsResp, error) {
 
 	// Construct authorized request to request data from the API.
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, APINotify, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.apiURL(PathNotify), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build http request: %w", err)
 	}
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token.AccessToken))
+	req = authorize(req, token)
 
 	// Updating the query with the parameters generated by the param provided.
 	req.URL.RawQuery = param.UpdateQuery(req.URL.Query(), c.clientID).Encode()
 
 	// Executing the request.
-	resp, err := c.HttpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read body of request: %w", err)
-	}
-
 	var mResp SubscribeToNotificationsResp
-	if err = json.Unmarshal(body, &mResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	if err = c.do(req, &mResp); err != nil {
+		return nil, err
 	}
 
 	switch mResp.Status {
 	case 0:
 		return &mResp, nil
 	default:
-		return &mResp, fmt.Errorf("api returned an error: %s", mResp.APIError)
+		return &mResp, newAPIError(req, mResp.Status, mResp.APIError)
 	}
 }
 
@@ -114,36 +102,26 @@ func (p *GetNotificationParam) UpdateQuery(q url.Values) url.Values {
 func (c *Client) GetNotification(ctx context.Context, token AccessToken, param GetNotificationParam) (*GetNotificationResp, error) {
 
 	// Construct authorized request to request data from the API.
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, APINotify, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.apiURL(PathNotify), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build http request: %w", err)
 	}
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token.AccessToken))
+	req = authorize(req, token)
 
 	// Updating the query with the parameters generated by the param provided.
 	req.URL.RawQuery = param.UpdateQuery(req.URL.Query()).Encode()
 
 	// Executing the request.
-	resp, err := c.HttpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read body of request: %w", err)
-	}
-
 	var mResp GetNotificationResp
-	if err = json.Unmarshal(body, &mResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	if err = c.do(req, &mResp); err != nil {
+		return nil, err
 	}
 
 	switch mResp.Status {
 	case 0:
 		return &mResp, nil
 	default:
-		return &mResp, fmt.Errorf("api returned an error: %s", mResp.APIError)
+		return &mResp, newAPIError(req, mResp.Status, mResp.APIError)
 	}
 }
 
@@ -189,38 +167,42 @@ func (p *ListNotificationParam) UpdateQuery(q url.Values) url.Values {
 
 // ListNotification lists all notifications the user is subscribed to.
 func (c *Client) ListNotification(ctx context.Context, token AccessToken, param ListNotificationParam) (*ListNotificationResp, error) {
+	return c.listNotification(ctx, token, param.UpdateQuery)
+}
+
+// listAllNotifications lists the notifications of every category the user is subscribed to. The appli parameter is
+// omitted which the API treats as every category.
+func (c *Client) listAllNotifications(ctx context.Context, token AccessToken) (*ListNotificationResp, error) {
+	return c.listNotification(ctx, token, func(q url.Values) url.Values {
+		q.Set("action", APIActionNotificationList)
+		return q
+	})
+}
+
+// listNotification lists the notifications the user is subscribed to with the query built by updateQuery.
+func (c *Client) listNotification(ctx context.Context, token AccessToken, updateQuery func(q url.Values) url.Values) (*ListNotificationResp, error) {
 
 	// Construct authorized request to request data from the API.
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, APINotify, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.apiURL(PathNotify), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build http request: %w", err)
 	}
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token.AccessToken))
+	req = authorize(req, token)
 
-	// Updating the query with the parameters generated by the param provided.
-	req.URL.RawQuery = param.UpdateQuery(req.URL.Query()).Encode()
+	// Updating the query with the parameters provided.
+	req.URL.RawQuery = updateQuery(req.URL.Query()).Encode()
 
 	// Executing the request.
-	resp, err := c.HttpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read body of request: %w", err)
-	}
-
 	var mResp ListNotificationResp
-	if err = json.Unmarshal(body, &mResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	if err = c.do(req, &mResp); err != nil {
+		return nil, err
 	}
 
 	switch mResp.Status {
 	case 0:
 		return &mResp, nil
 	default:
-		return &mResp, fmt.Errorf("api returned an error: %s", mResp.APIError)
+		return &mResp, newAPIError(req, mResp.Status, mResp.APIError)
 	}
 }
 
@@ -255,36 +237,26 @@ func (p *RevokeNotificationParam) UpdateQuery(q url.Values) url.Values {
 func (c *Client) RevokeNotification(ctx context.Context, token AccessToken, param RevokeNotificationParam) (*RevokeNotificationResp, error) {
 
 	// Construct authorized request to request data from the API.
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, APINotify, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.apiURL(PathNotify), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build http request: %w", err)
 	}
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token.AccessToken))
+	req = authorize(req, token)
 
 	// Updating the query with the parameters generated by the param provided.
 	req.URL.RawQuery = param.UpdateQuery(req.URL.Query()).Encode()
 
 	// Executing the request.
-	resp, err := c.HttpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read body of request: %w", err)
-	}
-
 	var mResp RevokeNotificationResp
-	if err = json.Unmarshal(body, &mResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	if err = c.do(req, &mResp); err != nil {
+		return nil, err
 	}
 
 	switch mResp.Status {
 	case 0:
 		return &mResp, nil
 	default:
-		return &mResp, fmt.Errorf("api returned an error: %s", mResp.APIError)
+		return &mResp, newAPIError(req, mResp.Status, mResp.APIError)
 	}
 }
 
@@ -344,35 +316,25 @@ func (p *UpdateNotificationParam) UpdateQuery(q url.Values) url.Values {
 func (c *Client) UpdateNotification(ctx context.Context, token AccessToken, param UpdateNotificationParam) (*UpdateNotificationResp, error) {
 
 	// Construct authorized request to request data from the API.
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, APINotify, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.apiURL(PathNotify), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build http request: %w", err)
 	}
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token.AccessToken))
+	req = authorize(req, token)
 
 	// Updating the query with the parameters generated by the param provided.
 	req.URL.RawQuery = param.UpdateQuery(req.URL.Query()).Encode()
 
 	// Executing the request.
-	resp, err := c.HttpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
-	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read body of request: %w", err)
-	}
-
 	var mResp UpdateNotificationResp
-	if err = json.Unmarshal(body, &mResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	if err = c.do(req, &mResp); err != nil {
+		return nil, err
 	}
 
 	switch mResp.Status {
 	case 0:
 		return &mResp, nil
 	default:
-		return &mResp, fmt.Errorf("api returned an error: %s", mResp.APIError)
+		return &mResp, newAPIError(req, mResp.Status, mResp.APIError)
 	}
 }
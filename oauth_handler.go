@@ -0,0 +1,246 @@
+package withings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// DefaultStateTTL is how long the state of an authorization request remains valid by default.
+const DefaultStateTTL = 10 * time.Minute
+
+// Reasons passed in the error query parameter of the failure redirect of an OAuthCallbackHandler. If the user denied
+// access or Withings reported another failure the error returned by Withings is passed instead.
+const (
+	CallbackErrorInvalidState  = "invalid_state"
+	CallbackErrorMissingCode   = "missing_code"
+	CallbackErrorTokenExchange = "token_exchange_failed"
+	CallbackErrorTokenSink     = "token_sink_failed"
+	CallbackErrorServer        = "server_error"
+)
+
+// StateStore stores the states of pending authorization requests until the callback of the request is received.
+// Implementations must be safe for concurrent use.
+type StateStore interface {
+	// Save stores the state until expiresAt.
+	Save(ctx context.Context, state string, expiresAt time.Time) error
+
+	// Consume removes the state and reports whether it was stored and had not expired. A state can only be consumed
+	// once.
+	Consume(ctx context.Context, state string) (bool, error)
+}
+
+// MemoryStateStore is a StateStore that keeps states in memory. Expired states are discarded as new states are saved.
+type MemoryStateStore struct {
+	mu     sync.Mutex
+	states map[string]time.Time
+}
+
+// NewMemoryStateStore returns an empty MemoryStateStore.
+func NewMemoryStateStore() *MemoryStateStore {
+	return &MemoryStateStore{
+		states: make(map[string]time.Time),
+	}
+}
+
+// Save stores the state until expiresAt.
+func (s *MemoryStateStore) Save(_ context.Context, state string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for k, exp := range s.states {
+		if now.After(exp) {
+			delete(s.states, k)
+		}
+	}
+
+	s.states[state] = expiresAt
+	return nil
+}
+
+// Consume removes the state and reports whether it was stored and had not expired.
+func (s *MemoryStateStore) Consume(_ context.Context, state string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	exp, ok := s.states[state]
+	if !ok {
+		return false, nil
+	}
+	delete(s.states, state)
+
+	return !time.Now().After(exp), nil
+}
+
+// TokenSink receives the token of a user that granted access through an OAuthCallbackHandler. The token should be
+// persisted, for example in a TokenStore. Returning an error fails the authorization.
+type TokenSink func(ctx context.Context, token AccessToken) error
+
+// OAuthCallbackHandler is an http.Handler serving the redirect URL of web applications. It validates the state of the
+// callback against the StateStore, exchanges the code for an access token, passes the token to the TokenSink and
+// redirects the browser to the success or failure URL.
+type OAuthCallbackHandler struct {
+	c        *Client
+	sink     TokenSink
+	states   StateStore
+	stateTTL time.Duration
+
+	// The URLs the browser is redirected to. If empty a plain response is written instead.
+	successURL string
+	failureURL string
+
+	// Called with every failed callback. Used for logging by the application.
+	onError func(r *http.Request, err error)
+}
+
+// OAuthCallbackHandlerOption is an option that can be applied to an OAuthCallbackHandler.
+type OAuthCallbackHandlerOption func(h *OAuthCallbackHandler)
+
+// WithStateStore configures the handler to use the store provided for states. By default states are stored in a
+// MemoryStateStore, which requires the authorization request and callback to be handled by the same process.
+func WithStateStore(store StateStore) OAuthCallbackHandlerOption {
+	return func(h *OAuthCallbackHandler) {
+		h.states = store
+	}
+}
+
+// WithStateTTL configures how long the state of an authorization request remains valid. The default is
+// DefaultStateTTL.
+func WithStateTTL(ttl time.Duration) OAuthCallbackHandlerOption {
+	return func(h *OAuthCallbackHandler) {
+		h.stateTTL = ttl
+	}
+}
+
+// WithSuccessRedirect configures the handler to redirect the browser to successURL once the token has been passed to
+// the sink.
+func WithSuccessRedirect(successURL string) OAuthCallbackHandlerOption {
+	return func(h *OAuthCallbackHandler) {
+		h.successURL = successURL
+	}
+}
+
+// WithFailureRedirect configures the handler to redirect the browser to failureURL when the authorization fails. The
+// reason is passed in the error query parameter, see the CallbackError constants.
+func WithFailureRedirect(failureURL string) OAuthCallbackHandlerOption {
+	return func(h *OAuthCallbackHandler) {
+		h.failureURL = failureURL
+	}
+}
+
+// WithCallbackErrorHandler configures the handler to call fn with the error of every failed callback.
+func WithCallbackErrorHandler(fn func(r *http.Request, err error)) OAuthCallbackHandlerOption {
+	return func(h *OAuthCallbackHandler) {
+		h.onError = fn
+	}
+}
+
+// NewOAuthCallbackHandler returns a handler for the redirect URL of the client that passes the tokens of users that
+// granted access to sink. Authorization requests must be generated with AuthRequestURL of the handler so their state
+// is stored.
+func (c *Client) NewOAuthCallbackHandler(sink TokenSink, opts ...OAuthCallbackHandlerOption) *OAuthCallbackHandler {
+	h := &OAuthCallbackHandler{
+		c:        c,
+		sink:     sink,
+		states:   NewMemoryStateStore(),
+		stateTTL: DefaultStateTTL,
+	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
+}
+
+// AuthRequestURL returns the URL the user must visit to grant access to the scopes provided. The state of the request
+// is saved to the StateStore of the handler.
+func (h *OAuthCallbackHandler) AuthRequestURL(ctx context.Context, scopes []string) (*url.URL, error) {
+	authURL, state, err := h.c.GetUserAuthRequestURL(scopes, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if err = h.states.Save(ctx, state, time.Now().Add(h.stateTTL)); err != nil {
+		return nil, fmt.Errorf("failed to save state: %w", err)
+	}
+
+	return authURL, nil
+}
+
+// ServeHTTP handles the authorization callback.
+func (h *OAuthCallbackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	valid, err := h.states.Consume(ctx, query.Get("state"))
+	if err != nil {
+		h.fail(w, r, CallbackErrorServer, fmt.Errorf("failed to consume state: %w", err))
+		return
+	}
+	if !valid {
+		h.fail(w, r, CallbackErrorInvalidState, ErrStateMismatch)
+		return
+	}
+
+	result := parseCallback(query)
+	if result.err != nil {
+		reason := query.Get("error")
+		if reason == "" {
+			reason = CallbackErrorMissingCode
+		}
+		h.fail(w, r, reason, result.err)
+		return
+	}
+
+	token, err := h.c.exchangeCode(ctx, result.code)
+	if err != nil {
+		h.fail(w, r, CallbackErrorTokenExchange, err)
+		return
+	}
+
+	if err = h.sink(ctx, *token); err != nil {
+		h.fail(w, r, CallbackErrorTokenSink, fmt.Errorf("failed to store token: %w", err))
+		return
+	}
+
+	if h.successURL != "" {
+		http.Redirect(w, r, h.successURL, http.StatusFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, callbackSuccessPage)
+}
+
+// fail reports the failed callback to the error handler and redirects to the failure URL with the reason provided.
+func (h *OAuthCallbackHandler) fail(w http.ResponseWriter, r *http.Request, reason string, err error) {
+	if h.onError != nil {
+		h.onError(r, err)
+	}
+
+	if h.failureURL == "" {
+		status := http.StatusBadRequest
+		if reason == CallbackErrorServer || reason == CallbackErrorTokenSink || errors.Is(err, ErrRetryable) {
+			status = http.StatusInternalServerError
+		}
+		http.Error(w, "authorization failed: "+reason, status)
+		return
+	}
+
+	failureURL, parseErr := url.Parse(h.failureURL)
+	if parseErr != nil {
+		http.Error(w, "authorization failed: "+reason, http.StatusInternalServerError)
+		return
+	}
+	q := failureURL.Query()
+	q.Set("error", reason)
+	failureURL.RawQuery = q.Encode()
+
+	http.Redirect(w, r, failureURL.String(), http.StatusFound)
+}
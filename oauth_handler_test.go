@@ -0,0 +1,187 @@
+package withings_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jrmycanady/withings"
+	"github.com/jrmycanady/withings/withingstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOAuthCallbackHandler(t *testing.T) {
+	t.Parallel()
+
+	srv := withingstest.NewServer()
+	defer srv.Close()
+	srv.AddUser(withingstest.NewDemoUser("1", time.Now()))
+	srv.SetAuthorizingUser("1")
+
+	tests := []struct {
+		name     string
+		opts     []withings.OAuthCallbackHandlerOption
+		sinkErr  error
+		allow    bool
+		before   func(authURL *url.URL) *url.URL
+		path     string
+		errorArg string
+		status   int
+		tokens   int
+	}{
+		{
+			name:   "Allowed",
+			allow:  true,
+			path:   "/success",
+			status: http.StatusOK,
+			tokens: 1,
+		},
+		{
+			name:     "Denied",
+			path:     "/failure",
+			errorArg: "access_denied",
+			status:   http.StatusOK,
+		},
+		{
+			name:  "Forged state",
+			allow: true,
+			before: func(authURL *url.URL) *url.URL {
+				q := authURL.Query()
+				q.Set("state", "forged")
+				authURL.RawQuery = q.Encode()
+				return authURL
+			},
+			path:     "/failure",
+			errorArg: withings.CallbackErrorInvalidState,
+			status:   http.StatusOK,
+		},
+		{
+			name:     "Expired state",
+			opts:     []withings.OAuthCallbackHandlerOption{withings.WithStateTTL(-time.Second)},
+			allow:    true,
+			path:     "/failure",
+			errorArg: withings.CallbackErrorInvalidState,
+			status:   http.StatusOK,
+		},
+		{
+			name:     "Sink fails",
+			sinkErr:  errors.New("database down"),
+			allow:    true,
+			path:     "/failure",
+			errorArg: withings.CallbackErrorTokenSink,
+			status:   http.StatusOK,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var tokens []withings.AccessToken
+			var handler http.Handler
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
+				handler.ServeHTTP(w, r)
+			})
+			mux.HandleFunc("/success", func(w http.ResponseWriter, r *http.Request) {})
+			mux.HandleFunc("/failure", func(w http.ResponseWriter, r *http.Request) {})
+			app := httptest.NewServer(mux)
+			defer app.Close()
+
+			appURL, err := url.Parse(app.URL + "/callback")
+			require.Nil(t, err)
+			c := srv.NewClient(*appURL)
+
+			opts := append([]withings.OAuthCallbackHandlerOption{
+				withings.WithSuccessRedirect(app.URL + "/success"),
+				withings.WithFailureRedirect(app.URL + "/failure"),
+			}, test.opts...)
+			h := c.NewOAuthCallbackHandler(func(ctx context.Context, token withings.AccessToken) error {
+				mu.Lock()
+				defer mu.Unlock()
+
+				tokens = append(tokens, token)
+				return test.sinkErr
+			}, opts...)
+			handler = h
+
+			authURL, err := h.AuthRequestURL(context.Background(), []string{withings.ScopeUserMetrics})
+			require.Nil(t, err)
+			if test.before != nil {
+				authURL = test.before(authURL)
+			}
+
+			resp, err := consent(authURL, test.allow)
+			require.Nil(t, err)
+			resp.Body.Close()
+
+			assert.Equal(t, test.status, resp.StatusCode)
+			assert.Equal(t, test.path, resp.Request.URL.Path)
+			assert.Equal(t, test.errorArg, resp.Request.URL.Query().Get("error"))
+			if test.sinkErr == nil {
+				assert.Len(t, tokens, test.tokens)
+			}
+		})
+	}
+}
+
+func TestOAuthCallbackHandler_NoRedirects(t *testing.T) {
+	t.Parallel()
+
+	srv := withingstest.NewServer()
+	defer srv.Close()
+	srv.AddUser(withingstest.NewDemoUser("1", time.Now()))
+	srv.SetAuthorizingUser("1")
+
+	var handler http.Handler
+	app := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r)
+	}))
+	defer app.Close()
+
+	appURL, err := url.Parse(app.URL + "/callback")
+	require.Nil(t, err)
+	c := srv.NewClient(*appURL)
+
+	var callbackErrs []error
+	var token withings.AccessToken
+	h := c.NewOAuthCallbackHandler(func(ctx context.Context, t withings.AccessToken) error {
+		token = t
+		return nil
+	}, withings.WithCallbackErrorHandler(func(r *http.Request, err error) {
+		callbackErrs = append(callbackErrs, err)
+	}))
+	handler = h
+
+	authURL, err := h.AuthRequestURL(context.Background(), []string{withings.ScopeUserMetrics})
+	require.Nil(t, err)
+
+	// The code exchange fails once, the state is consumed so the callback cannot be replayed.
+	srv.Fail(withingstest.Failure{Path: withings.PathOAuth2, Status: withings.StatusInvalidParams})
+	resp, err := consent(authURL, true)
+	require.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	require.Len(t, callbackErrs, 1)
+
+	resp, err = http.Get(resp.Request.URL.String())
+	require.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	require.Len(t, callbackErrs, 2)
+	assert.True(t, errors.Is(callbackErrs[1], withings.ErrStateMismatch))
+
+	authURL, err = h.AuthRequestURL(context.Background(), []string{withings.ScopeUserMetrics})
+	require.Nil(t, err)
+	resp, err = consent(authURL, true)
+	require.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NotEmpty(t, token.AccessToken)
+}
@@ -0,0 +1,147 @@
+package withings
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+var (
+	// ErrStateMismatch is returned when the state of an authorization callback does not match the state the
+	// authorization request was generated with. The callback may have been forged.
+	ErrStateMismatch = errors.New("state mismatch")
+
+	// ErrAuthorizationDenied is returned when the user did not grant access or the authorization failed. The error
+	// returned by Withings is included in the message of the wrapping error.
+	ErrAuthorizationDenied = errors.New("authorization denied")
+)
+
+// loopbackShutdownTimeout is how long the loopback server waits for the callback response to be delivered before it
+// is closed.
+const loopbackShutdownTimeout = 5 * time.Second
+
+// callbackSuccessPage is served to the browser once the callback has been received.
+const callbackSuccessPage = `<html>
+<body>
+<p>Authorization complete. You may close this window.</p>
+</body>
+</html>
+`
+
+// callbackResult is the outcome of an authorization callback.
+type callbackResult struct {
+	code string
+	err  error
+}
+
+// AuthorizeLoopback performs the complete authorization flow for interactive applications such as CLIs. A temporary
+// HTTP server is started on the host and port of the redirect URL of the client, which must be a plain http URL. The
+// authorization URL for the scopes is passed to openURL, which should display it to the user or open it in a browser.
+// Once the user grants access the callback is served, its state validated and the code exchanged for an access
+// token. Callbacks with a state other than the one generated, which may have been forged, are answered with an
+// ErrStateMismatch error and otherwise ignored. The server is shut down before returning. AuthorizeLoopback blocks
+// until a callback with the expected state is received or ctx ends.
+func (c *Client) AuthorizeLoopback(ctx context.Context, scopes []string, openURL func(authURL *url.URL) error) (*AccessToken, error) {
+	if c.redirectURL.Scheme != "http" || c.redirectURL.Host == "" {
+		return nil, fmt.Errorf("redirect url must be an http url with a host to listen on: %s", c.redirectURL.String())
+	}
+
+	addr := c.redirectURL.Host
+	if c.redirectURL.Port() == "" {
+		addr = net.JoinHostPort(c.redirectURL.Hostname(), "80")
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	authURL, state, err := c.GetUserAuthRequestURL(scopes, "")
+	if err != nil {
+		ln.Close()
+		return nil, err
+	}
+
+	path := c.redirectURL.Path
+	if path == "" {
+		path = "/"
+	}
+
+	results := make(chan callbackResult, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		// A callback without the expected state must not end the flow, it may be a forgery racing the user.
+		if subtle.ConstantTimeCompare([]byte(r.URL.Query().Get("state")), []byte(state)) != 1 {
+			http.Error(w, ErrStateMismatch.Error(), http.StatusBadRequest)
+			return
+		}
+
+		result := parseCallback(r.URL.Query())
+		if result.err != nil {
+			http.Error(w, result.err.Error(), http.StatusBadRequest)
+		} else {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprint(w, callbackSuccessPage)
+		}
+
+		// Only the first callback is used, any following are ignored.
+		select {
+		case results <- result:
+		default:
+		}
+	})
+
+	srv := &http.Server{Handler: mux}
+	go srv.Serve(ln)
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), loopbackShutdownTimeout)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	if openURL != nil {
+		if err = openURL(authURL); err != nil {
+			return nil, fmt.Errorf("failed to open authorization url: %w", err)
+		}
+	}
+
+	var result callbackResult
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("failed waiting for authorization callback: %w", ctx.Err())
+	case result = <-results:
+	}
+	if result.err != nil {
+		return nil, result.err
+	}
+
+	return c.exchangeCode(ctx, result.code)
+}
+
+// parseCallback extracts the code from the query of an authorization callback. The state must already have been
+// validated.
+func parseCallback(query url.Values) callbackResult {
+	if e := query.Get("error"); e != "" {
+		return callbackResult{err: fmt.Errorf("%w: %s", ErrAuthorizationDenied, e)}
+	}
+	if query.Get("code") == "" {
+		return callbackResult{err: fmt.Errorf("%w: no code was provided", ErrAuthorizationDenied)}
+	}
+
+	return callbackResult{code: query.Get("code")}
+}
+
+// exchangeCode exchanges the code of an authorization callback for an access token.
+func (c *Client) exchangeCode(ctx context.Context, code string) (*AccessToken, error) {
+	resp, err := c.GetUserAccessTokenContext(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get access token: %w", err)
+	}
+
+	return &resp.AccessToken, nil
+}
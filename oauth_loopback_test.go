@@ -0,0 +1,172 @@
+package withings_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jrmycanady/withings"
+	"github.com/jrmycanady/withings/withingstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// csrfRegex extracts the CSRF token from the authorization page.
+var csrfRegex = regexp.MustCompile(`name="csrf_token" value="([^"]+)"`)
+
+// consent acts as the browser of the user, submitting the authorization page at authURL and following the redirect
+// to the callback. The response of the callback is returned.
+func consent(authURL *url.URL, allow bool) (*http.Response, error) {
+	resp, err := http.Get(authURL.String())
+	if err != nil {
+		return nil, err
+	}
+	page, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	m := csrfRegex.FindSubmatch(page)
+	if m == nil {
+		return nil, fmt.Errorf("csrf token not found")
+	}
+
+	form := url.Values{}
+	form.Set("csrf_token", string(m[1]))
+	if allow {
+		form.Set("authorized", "1")
+	}
+
+	return http.Post(authURL.String(), "application/x-www-form-urlencoded", strings.NewReader(form.Encode()))
+}
+
+// loopbackRedirectURL returns a redirect URL on a free loopback port.
+func loopbackRedirectURL(t *testing.T) url.URL {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer ln.Close()
+
+	return url.URL{Scheme: "http", Host: ln.Addr().String(), Path: "/callback"}
+}
+
+func TestClient_AuthorizeLoopback(t *testing.T) {
+	t.Parallel()
+
+	srv := withingstest.NewServer()
+	defer srv.Close()
+	srv.AddUser(withingstest.NewDemoUser("1", time.Now()))
+	srv.SetAuthorizingUser("1")
+
+	tests := []struct {
+		name     string
+		open     func(authURL *url.URL) error
+		checkErr func(t *testing.T, err error)
+	}{
+		{
+			name: "Allowed",
+			open: func(authURL *url.URL) error {
+				go consent(authURL, true)
+				return nil
+			},
+		},
+		{
+			name: "Denied",
+			open: func(authURL *url.URL) error {
+				go consent(authURL, false)
+				return nil
+			},
+			checkErr: func(t *testing.T, err error) {
+				assert.True(t, errors.Is(err, withings.ErrAuthorizationDenied))
+				assert.Contains(t, err.Error(), "access_denied")
+			},
+		},
+		{
+			name: "Forged state",
+			open: func(authURL *url.URL) error {
+				// The forged callback is rejected and the flow completes with the callback of the user.
+				callback, _ := url.Parse(authURL.Query().Get("redirect_uri"))
+				callback.RawQuery = url.Values{"code": {"forged"}, "state": {"forged"}}.Encode()
+				resp, err := http.Get(callback.String())
+				if err != nil {
+					return err
+				}
+				resp.Body.Close()
+				if resp.StatusCode != http.StatusBadRequest {
+					return fmt.Errorf("forged callback got status %d", resp.StatusCode)
+				}
+
+				go consent(authURL, true)
+				return nil
+			},
+		},
+		{
+			name: "Forged denial",
+			open: func(authURL *url.URL) error {
+				callback, _ := url.Parse(authURL.Query().Get("redirect_uri"))
+				callback.RawQuery = url.Values{"error": {"access_denied"}, "state": {"forged"}}.Encode()
+				resp, err := http.Get(callback.String())
+				if err != nil {
+					return err
+				}
+				resp.Body.Close()
+
+				go consent(authURL, true)
+				return nil
+			},
+		},
+		{
+			name: "Open fails",
+			open: func(authURL *url.URL) error {
+				return errors.New("no browser")
+			},
+			checkErr: func(t *testing.T, err error) {
+				assert.Contains(t, err.Error(), "no browser")
+			},
+		},
+		{
+			name: "Timeout",
+			open: func(authURL *url.URL) error {
+				return nil
+			},
+			checkErr: func(t *testing.T, err error) {
+				assert.True(t, errors.Is(err, context.DeadlineExceeded))
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := srv.NewClient(loopbackRedirectURL(t))
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+
+			token, err := c.AuthorizeLoopback(ctx, []string{withings.ScopeUserMetrics}, test.open)
+			if test.checkErr != nil {
+				require.NotNil(t, err)
+				test.checkErr(t, err)
+				return
+			}
+
+			require.Nil(t, err)
+			require.NotNil(t, token)
+			resp, err := c.GetMeasure(ctx, *token, withings.GetMeasureParam{})
+			require.Nil(t, err)
+			assert.NotEmpty(t, resp.Body.MeasureGroups)
+		})
+	}
+
+	t.Run("Requires http redirect url", func(t *testing.T) {
+		c := srv.NewClient(url.URL{Scheme: "https", Host: "example.com", Path: "/callback"})
+		_, err := c.AuthorizeLoopback(context.Background(), nil, nil)
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,536 @@
+package withings
+
+import (
+	"context"
+	"fmt"
+)
+
+// pager follows the more and offset values of a paged endpoint. It is embedded by the iterators of each endpoint.
+type pager struct {
+	started bool
+	more    bool
+	offset  int64
+	pages   int
+	err     error
+
+	// The number of items of the current page and the index of the current item.
+	count int
+	index int
+}
+
+// next advances to the next item, fetching pages with fetch until a page has items. fetch stores the items of the
+// page fetched at the offset provided and returns their count along with the more and offset values of the page. It
+// returns false once the items of the last page have been consumed or an error occurred.
+func (p *pager) next(ctx context.Context, fetch func(ctx context.Context, offset int64) (count int, more bool, next int64, err error)) bool {
+	p.index++
+	for p.index >= p.count {
+		ok := p.nextPage(ctx, func(ctx context.Context, offset int64) (bool, int64, error) {
+			count, more, next, err := fetch(ctx, offset)
+			if err != nil {
+				return false, 0, err
+			}
+			p.count = count
+			return more, next, nil
+		})
+		if !ok {
+			return false
+		}
+		p.index = 0
+	}
+
+	return true
+}
+
+// nextPage fetches the next page with fetch, providing the offset of the page. It returns false once the last page
+// has been fetched or an error occurred.
+func (p *pager) nextPage(ctx context.Context, fetch func(ctx context.Context, offset int64) (more bool, next int64, err error)) bool {
+	if p.err != nil || (p.started && !p.more) {
+		return false
+	}
+
+	more, next, err := fetch(ctx, p.offset)
+	if err != nil {
+		p.err = err
+		return false
+	}
+
+	// Guarding against an API response that would page forever.
+	if more && next <= p.offset {
+		p.err = fmt.Errorf("api returned more pages without advancing the offset past %d", p.offset)
+		return false
+	}
+
+	p.started = true
+	p.pages++
+	p.more = more
+	p.offset = next
+
+	return true
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (p *pager) Err() error {
+	return p.err
+}
+
+// Pages returns the number of pages fetched so far.
+func (p *pager) Pages() int {
+	return p.pages
+}
+
+// ActivityIterator iterates over the activities of every page returned by GetActivity. Iteration starts at the
+// offset of the param provided.
+type ActivityIterator struct {
+	pager
+	fetch   func(ctx context.Context, param GetActivityParam) (*GetActivityResp, error)
+	param   GetActivityParam
+	items   Activities
+	current Activity
+}
+
+// IterateActivities returns an iterator over the activities for the user represented by the token.
+func (c *Client) IterateActivities(token AccessToken, param GetActivityParam) *ActivityIterator {
+	return &ActivityIterator{
+		pager: pager{offset: param.Offset},
+		fetch: func(ctx context.Context, param GetActivityParam) (*GetActivityResp, error) {
+			return c.GetActivity(ctx, token, param)
+		},
+		param: param,
+	}
+}
+
+// Next advances the iterator to the next activity, fetching the next page if needed. It returns false when there
+// are no more activities or an error occurred, which is available from Err.
+func (it *ActivityIterator) Next(ctx context.Context) bool {
+	ok := it.next(ctx, func(ctx context.Context, offset int64) (int, bool, int64, error) {
+		it.param.Offset = offset
+		resp, err := it.fetch(ctx, it.param)
+		if err != nil {
+			return 0, false, 0, err
+		}
+		it.items = resp.Body.Activities
+		return len(it.items), resp.Body.More, resp.Body.Offset, nil
+	})
+	if !ok {
+		return false
+	}
+
+	it.current = it.items[it.index]
+	return true
+}
+
+// Activity returns the current activity.
+func (it *ActivityIterator) Activity() Activity {
+	return it.current
+}
+
+// GetAllActivities retrieves the activities of every page for the user represented by the token.
+func (c *Client) GetAllActivities(ctx context.Context, token AccessToken, param GetActivityParam) (Activities, error) {
+	it := c.IterateActivities(token, param)
+	activities := make(Activities, 0)
+	for it.Next(ctx) {
+		activities = append(activities, it.Activity())
+	}
+	return activities, it.Err()
+}
+
+// WorkoutIterator iterates over the workouts of every page returned by GetWorkout. Iteration starts at the offset
+// of the param provided.
+type WorkoutIterator struct {
+	pager
+	fetch   func(ctx context.Context, param GetWorkoutParam) (*GetWorkoutResp, error)
+	param   GetWorkoutParam
+	items   Workouts
+	current Workout
+}
+
+// IterateWorkouts returns an iterator over the workouts for the user represented by the token.
+func (c *Client) IterateWorkouts(token AccessToken, param GetWorkoutParam) *WorkoutIterator {
+	return &WorkoutIterator{
+		pager: pager{offset: param.Offset},
+		fetch: func(ctx context.Context, param GetWorkoutParam) (*GetWorkoutResp, error) {
+			return c.GetWorkout(ctx, token, param)
+		},
+		param: param,
+	}
+}
+
+// Next advances the iterator to the next workout, fetching the next page if needed. It returns false when there
+// are no more workouts or an error occurred, which is available from Err.
+func (it *WorkoutIterator) Next(ctx context.Context) bool {
+	ok := it.next(ctx, func(ctx context.Context, offset int64) (int, bool, int64, error) {
+		it.param.Offset = offset
+		resp, err := it.fetch(ctx, it.param)
+		if err != nil {
+			return 0, false, 0, err
+		}
+		it.items = resp.Body.Series
+		return len(it.items), resp.Body.More, resp.Body.Offset, nil
+	})
+	if !ok {
+		return false
+	}
+
+	it.current = it.items[it.index]
+	return true
+}
+
+// Workout returns the current workout.
+func (it *WorkoutIterator) Workout() Workout {
+	return it.current
+}
+
+// GetAllWorkouts retrieves the workouts of every page for the user represented by the token.
+func (c *Client) GetAllWorkouts(ctx context.Context, token AccessToken, param GetWorkoutParam) (Workouts, error) {
+	it := c.IterateWorkouts(token, param)
+	workouts := make(Workouts, 0)
+	for it.Next(ctx) {
+		workouts = append(workouts, it.Workout())
+	}
+	return workouts, it.Err()
+}
+
+// HeartIterator iterates over the heart data of every page returned by GetHeartList. Iteration starts at the offset
+// of the param provided.
+type HeartIterator struct {
+	pager
+	fetch   func(ctx context.Context, param GetHeartListParam) (*GetHeartResp, error)
+	param   GetHeartListParam
+	items   HeartDatas
+	current HeartData
+}
+
+// IterateHeartList returns an iterator over the heart data for the user represented by the token.
+func (c *Client) IterateHeartList(token AccessToken, param GetHeartListParam) *HeartIterator {
+	return &HeartIterator{
+		pager: pager{offset: param.Offset},
+		fetch: func(ctx context.Context, param GetHeartListParam) (*GetHeartResp, error) {
+			return c.GetHeartList(ctx, token, param)
+		},
+		param: param,
+	}
+}
+
+// Next advances the iterator to the next heart data, fetching the next page if needed. It returns false when there
+// is no more heart data or an error occurred, which is available from Err.
+func (it *HeartIterator) Next(ctx context.Context) bool {
+	ok := it.next(ctx, func(ctx context.Context, offset int64) (int, bool, int64, error) {
+		it.param.Offset = offset
+		resp, err := it.fetch(ctx, it.param)
+		if err != nil {
+			return 0, false, 0, err
+		}
+		it.items = resp.Body.Series
+		return len(it.items), resp.Body.More, resp.Body.Offset, nil
+	})
+	if !ok {
+		return false
+	}
+
+	it.current = it.items[it.index]
+	return true
+}
+
+// HeartData returns the current heart data.
+func (it *HeartIterator) HeartData() HeartData {
+	return it.current
+}
+
+// GetAllHeartList retrieves the heart data of every page for the user represented by the token.
+func (c *Client) GetAllHeartList(ctx context.Context, token AccessToken, param GetHeartListParam) (HeartDatas, error) {
+	it := c.IterateHeartList(token, param)
+	heartData := make(HeartDatas, 0)
+	for it.Next(ctx) {
+		heartData = append(heartData, it.HeartData())
+	}
+	return heartData, it.Err()
+}
+
+// SleepSummaryIterator iterates over the sleep summaries of every page returned by GetSleepSummary. Iteration starts
+// at the offset of the param provided.
+type SleepSummaryIterator struct {
+	pager
+	fetch   func(ctx context.Context, param GetSleepSummaryParam) (*GetSleepSummaryResp, error)
+	param   GetSleepSummaryParam
+	items   SleepSummaries
+	current SleepSummary
+}
+
+// IterateSleepSummaries returns an iterator over the sleep summaries for the user represented by the token.
+func (c *Client) IterateSleepSummaries(token AccessToken, param GetSleepSummaryParam) *SleepSummaryIterator {
+	return &SleepSummaryIterator{
+		pager: pager{offset: param.Offset},
+		fetch: func(ctx context.Context, param GetSleepSummaryParam) (*GetSleepSummaryResp, error) {
+			return c.GetSleepSummary(ctx, token, param)
+		},
+		param: param,
+	}
+}
+
+// Next advances the iterator to the next sleep summary, fetching the next page if needed. It returns false when
+// there are no more sleep summaries or an error occurred, which is available from Err.
+func (it *SleepSummaryIterator) Next(ctx context.Context) bool {
+	ok := it.next(ctx, func(ctx context.Context, offset int64) (int, bool, int64, error) {
+		it.param.Offset = offset
+		resp, err := it.fetch(ctx, it.param)
+		if err != nil {
+			return 0, false, 0, err
+		}
+		it.items = resp.Body.Series
+		return len(it.items), resp.Body.More, resp.Body.Offset, nil
+	})
+	if !ok {
+		return false
+	}
+
+	it.current = it.items[it.index]
+	return true
+}
+
+// SleepSummary returns the current sleep summary.
+func (it *SleepSummaryIterator) SleepSummary() SleepSummary {
+	return it.current
+}
+
+// GetAllSleepSummaries retrieves the sleep summaries of every page for the user represented by the token.
+func (c *Client) GetAllSleepSummaries(ctx context.Context, token AccessToken, param GetSleepSummaryParam) (SleepSummaries, error) {
+	it := c.IterateSleepSummaries(token, param)
+	summaries := make(SleepSummaries, 0)
+	for it.Next(ctx) {
+		summaries = append(summaries, it.SleepSummary())
+	}
+	return summaries, it.Err()
+}
+
+// MeasureGroupIterator iterates over the measure groups of every page returned by GetMeasure. Iteration starts at
+// the offset of the param provided.
+type MeasureGroupIterator struct {
+	pager
+	fetch   func(ctx context.Context, param GetMeasureParam) (*GetMeasureResp, error)
+	param   GetMeasureParam
+	items   MeasureGroups
+	current MeasureGroup
+}
+
+// IterateMeasureGroups returns an iterator over the measure groups for the user represented by the token.
+func (c *Client) IterateMeasureGroups(token AccessToken, param GetMeasureParam) *MeasureGroupIterator {
+	return &MeasureGroupIterator{
+		pager: pager{offset: param.Offset},
+		fetch: func(ctx context.Context, param GetMeasureParam) (*GetMeasureResp, error) {
+			return c.GetMeasure(ctx, token, param)
+		},
+		param: param,
+	}
+}
+
+// Next advances the iterator to the next measure group, fetching the next page if needed. It returns false when
+// there are no more measure groups or an error occurred, which is available from Err.
+func (it *MeasureGroupIterator) Next(ctx context.Context) bool {
+	ok := it.next(ctx, func(ctx context.Context, offset int64) (int, bool, int64, error) {
+		it.param.Offset = offset
+		resp, err := it.fetch(ctx, it.param)
+		if err != nil {
+			return 0, false, 0, err
+		}
+		it.items = resp.Body.MeasureGroups
+		return len(it.items), resp.Body.More != 0, resp.Body.Offset, nil
+	})
+	if !ok {
+		return false
+	}
+
+	it.current = it.items[it.index]
+	return true
+}
+
+// MeasureGroup returns the current measure group.
+func (it *MeasureGroupIterator) MeasureGroup() MeasureGroup {
+	return it.current
+}
+
+// GetAllMeasureGroups retrieves the measure groups of every page for the user represented by the token.
+func (c *Client) GetAllMeasureGroups(ctx context.Context, token AccessToken, param GetMeasureParam) (MeasureGroups, error) {
+	it := c.IterateMeasureGroups(token, param)
+	groups := make(MeasureGroups, 0)
+	for it.Next(ctx) {
+		groups = append(groups, it.MeasureGroup())
+	}
+	return groups, it.Err()
+}
+
+// StethoRecordingIterator iterates over the stethoscope recordings of every page returned by GetStethoList. Iteration
+// starts at the offset of the param provided.
+type StethoRecordingIterator struct {
+	pager
+	fetch   func(ctx context.Context, param GetStethoListParam) (*GetStethoListResp, error)
+	param   GetStethoListParam
+	items   StethoRecordings
+	current StethoRecording
+}
+
+// IterateStethoList returns an iterator over the stethoscope recordings for the user represented by the token.
+func (c *Client) IterateStethoList(token AccessToken, param GetStethoListParam) *StethoRecordingIterator {
+	return &StethoRecordingIterator{
+		pager: pager{offset: param.Offset},
+		fetch: func(ctx context.Context, param GetStethoListParam) (*GetStethoListResp, error) {
+			return c.GetStethoList(ctx, token, param)
+		},
+		param: param,
+	}
+}
+
+// Next advances the iterator to the next stethoscope recording, fetching the next page if needed. It returns false
+// when there are no more stethoscope recordings or an error occurred, which is available from Err.
+func (it *StethoRecordingIterator) Next(ctx context.Context) bool {
+	ok := it.next(ctx, func(ctx context.Context, offset int64) (int, bool, int64, error) {
+		it.param.Offset = offset
+		resp, err := it.fetch(ctx, it.param)
+		if err != nil {
+			return 0, false, 0, err
+		}
+		it.items = resp.Body.Series
+		return len(it.items), resp.Body.More, resp.Body.Offset, nil
+	})
+	if !ok {
+		return false
+	}
+
+	it.current = it.items[it.index]
+	return true
+}
+
+// StethoRecording returns the current stethoscope recording.
+func (it *StethoRecordingIterator) StethoRecording() StethoRecording {
+	return it.current
+}
+
+// GetAllStethoList retrieves the stethoscope recordings of every page for the user represented by the token.
+func (c *Client) GetAllStethoList(ctx context.Context, token AccessToken, param GetStethoListParam) (StethoRecordings, error) {
+	it := c.IterateStethoList(token, param)
+	recordings := make(StethoRecordings, 0)
+	for it.Next(ctx) {
+		recordings = append(recordings, it.StethoRecording())
+	}
+	return recordings, it.Err()
+}
+
+// IterateActivities returns an iterator over the activities for the AuthorizedUser. The token of the user is
+// refreshed as needed while fetching pages.
+func (a *AuthorizedUser) IterateActivities(param GetActivityParam) *ActivityIterator {
+	it := a.c.IterateActivities(AccessToken{}, param)
+	it.fetch = func(ctx context.Context, param GetActivityParam) (*GetActivityResp, error) {
+		resp, _, err := a.GetActivity(ctx, param)
+		return resp, err
+	}
+	return it
+}
+
+// GetAllActivities retrieves the activities of every page for the AuthorizedUser.
+func (a *AuthorizedUser) GetAllActivities(ctx context.Context, param GetActivityParam) (Activities, error) {
+	it := a.IterateActivities(param)
+	activities := make(Activities, 0)
+	for it.Next(ctx) {
+		activities = append(activities, it.Activity())
+	}
+	return activities, it.Err()
+}
+
+// IterateWorkouts returns an iterator over the workouts for the AuthorizedUser.
+func (a *AuthorizedUser) IterateWorkouts(param GetWorkoutParam) *WorkoutIterator {
+	it := a.c.IterateWorkouts(AccessToken{}, param)
+	it.fetch = func(ctx context.Context, param GetWorkoutParam) (*GetWorkoutResp, error) {
+		resp, _, err := a.GetWorkout(ctx, param)
+		return resp, err
+	}
+	return it
+}
+
+// GetAllWorkouts retrieves the workouts of every page for the AuthorizedUser.
+func (a *AuthorizedUser) GetAllWorkouts(ctx context.Context, param GetWorkoutParam) (Workouts, error) {
+	it := a.IterateWorkouts(param)
+	workouts := make(Workouts, 0)
+	for it.Next(ctx) {
+		workouts = append(workouts, it.Workout())
+	}
+	return workouts, it.Err()
+}
+
+// IterateHeartList returns an iterator over the heart data for the AuthorizedUser.
+func (a *AuthorizedUser) IterateHeartList(param GetHeartListParam) *HeartIterator {
+	it := a.c.IterateHeartList(AccessToken{}, param)
+	it.fetch = func(ctx context.Context, param GetHeartListParam) (*GetHeartResp, error) {
+		resp, _, err := a.GetHeartList(ctx, param)
+		return resp, err
+	}
+	return it
+}
+
+// GetAllHeartList retrieves the heart data of every page for the AuthorizedUser.
+func (a *AuthorizedUser) GetAllHeartList(ctx context.Context, param GetHeartListParam) (HeartDatas, error) {
+	it := a.IterateHeartList(param)
+	heartData := make(HeartDatas, 0)
+	for it.Next(ctx) {
+		heartData = append(heartData, it.HeartData())
+	}
+	return heartData, it.Err()
+}
+
+// IterateSleepSummaries returns an iterator over the sleep summaries for the AuthorizedUser.
+func (a *AuthorizedUser) IterateSleepSummaries(param GetSleepSummaryParam) *SleepSummaryIterator {
+	it := a.c.IterateSleepSummaries(AccessToken{}, param)
+	it.fetch = func(ctx context.Context, param GetSleepSummaryParam) (*GetSleepSummaryResp, error) {
+		resp, _, err := a.GetSleepSummary(ctx, param)
+		return resp, err
+	}
+	return it
+}
+
+// GetAllSleepSummaries retrieves the sleep summaries of every page for the AuthorizedUser.
+func (a *AuthorizedUser) GetAllSleepSummaries(ctx context.Context, param GetSleepSummaryParam) (SleepSummaries, error) {
+	it := a.IterateSleepSummaries(param)
+	summaries := make(SleepSummaries, 0)
+	for it.Next(ctx) {
+		summaries = append(summaries, it.SleepSummary())
+	}
+	return summaries, it.Err()
+}
+
+// IterateMeasureGroups returns an iterator over the measure groups for the AuthorizedUser.
+func (a *AuthorizedUser) IterateMeasureGroups(param GetMeasureParam) *MeasureGroupIterator {
+	it := a.c.IterateMeasureGroups(AccessToken{}, param)
+	it.fetch = func(ctx context.Context, param GetMeasureParam) (*GetMeasureResp, error) {
+		resp, _, err := a.GetMeasure(ctx, param)
+		return resp, err
+	}
+	return it
+}
+
+// GetAllMeasureGroups retrieves the measure groups of every page for the AuthorizedUser.
+func (a *AuthorizedUser) GetAllMeasureGroups(ctx context.Context, param GetMeasureParam) (MeasureGroups, error) {
+	it := a.IterateMeasureGroups(param)
+	groups := make(MeasureGroups, 0)
+	for it.Next(ctx) {
+		groups = append(groups, it.MeasureGroup())
+	}
+	return groups, it.Err()
+}
+
+// IterateStethoList returns an iterator over the stethoscope recordings for the AuthorizedUser.
+func (a *AuthorizedUser) IterateStethoList(param GetStethoListParam) *StethoRecordingIterator {
+	it := a.c.IterateStethoList(AccessToken{}, param)
+	it.fetch = func(ctx context.Context, param GetStethoListParam) (*GetStethoListResp, error) {
+		resp, _, err := a.GetStethoList(ctx, param)
+		return resp, err
+	}
+	return it
+}
+
+// GetAllStethoList retrieves the stethoscope recordings of every page for the AuthorizedUser.
+func (a *AuthorizedUser) GetAllStethoList(ctx context.Context, param GetStethoListParam) (StethoRecordings, error) {
+	it := a.IterateStethoList(param)
+	recordings := make(StethoRecordings, 0)
+	for it.Next(ctx) {
+		recordings = append(recordings, it.StethoRecording())
+	}
+	return recordings, it.Err()
+}
@@ -0,0 +1,95 @@
+package withings_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jrmycanady/withings"
+	"github.com/jrmycanady/withings/withingstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_GetAll(t *testing.T) {
+	t.Parallel()
+
+	srv := withingstest.NewServer(withingstest.WithPageSize(2))
+	defer srv.Close()
+	srv.AddUser(withingstest.NewDemoUser("1", time.Now()))
+	c := srv.NewClient(url.URL{})
+	token := srv.IssueToken("1")
+	ctx := context.Background()
+
+	groups, err := c.GetAllMeasureGroups(ctx, token, withings.GetMeasureParam{})
+	require.Nil(t, err)
+	assert.Len(t, groups, 7)
+
+	activities, err := c.GetAllActivities(ctx, token, withings.GetActivityParam{})
+	require.Nil(t, err)
+	assert.Len(t, activities, 7)
+
+	workouts, err := c.GetAllWorkouts(ctx, token, withings.GetWorkoutParam{})
+	require.Nil(t, err)
+	assert.Len(t, workouts, 7)
+
+	heartData, err := c.GetAllHeartList(ctx, token, withings.GetHeartListParam{})
+	require.Nil(t, err)
+	assert.Len(t, heartData, 7)
+
+	summaries, err := c.GetAllSleepSummaries(ctx, token, withings.GetSleepSummaryParam{})
+	require.Nil(t, err)
+	assert.Len(t, summaries, 7)
+
+	recordings, err := c.GetAllStethoList(ctx, token, withings.GetStethoListParam{})
+	require.Nil(t, err)
+	assert.Len(t, recordings, 7)
+	assert.Equal(t, 4, srv.RequestCount(withings.PathStethoV2, withings.APIActionGetStethoList))
+
+	assert.Equal(t, 4, srv.RequestCount(withings.PathMeasure, withings.APIActionGetMeasure))
+}
+
+func TestMeasureGroupIterator(t *testing.T) {
+	t.Parallel()
+
+	srv := withingstest.NewServer(withingstest.WithPageSize(3))
+	defer srv.Close()
+	srv.AddUser(withingstest.NewDemoUser("1", time.Now()))
+	c := srv.NewClient(url.URL{})
+	ctx := context.Background()
+
+	t.Run("Stops early", func(t *testing.T) {
+		it := c.IterateMeasureGroups(srv.IssueToken("1"), withings.GetMeasureParam{})
+		require.True(t, it.Next(ctx))
+		require.True(t, it.Next(ctx))
+		assert.NotZero(t, it.MeasureGroup().GroupID)
+		assert.Equal(t, 1, it.Pages())
+		assert.Nil(t, it.Err())
+	})
+
+	t.Run("Stops on error", func(t *testing.T) {
+		it := c.IterateMeasureGroups(srv.IssueToken("1"), withings.GetMeasureParam{})
+		var count int
+		for it.Next(ctx) {
+			count++
+			if count == 1 {
+				srv.Fail(withingstest.Failure{Path: withings.PathMeasure, Status: withings.StatusTooManyRequests})
+			}
+		}
+		assert.Equal(t, 3, count)
+		assert.True(t, errors.Is(it.Err(), withings.ErrRateLimited))
+	})
+
+	t.Run("AuthorizedUser", func(t *testing.T) {
+		u := c.NewAuthorizedUser(srv.IssueToken("1"))
+		groups, err := u.GetAllMeasureGroups(ctx, withings.GetMeasureParam{})
+		require.Nil(t, err)
+		assert.Len(t, groups, 7)
+
+		recordings, err := u.GetAllStethoList(ctx, withings.GetStethoListParam{})
+		require.Nil(t, err)
+		assert.Len(t, recordings, 7)
+	})
+}
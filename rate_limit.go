@@ -0,0 +1,321 @@
+package withings
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultRateLimitRequests is the number of requests per DefaultRateLimitInterval Withings allows an application
+	// to make by default.
+	DefaultRateLimitRequests = 120
+
+	// DefaultRateLimitInterval is the interval DefaultRateLimitRequests applies to.
+	DefaultRateLimitInterval = time.Minute
+
+	// userBucketSweepInterval is how often the buckets of idle users are discarded.
+	userBucketSweepInterval = time.Minute
+)
+
+// ErrInvalidRateLimit is returned by every request of a client configured with a rate limit whose requests or
+// interval is not positive.
+var ErrInvalidRateLimit = errors.New("invalid rate limit")
+
+// RateLimitStats are the statistics of the client side rate limiter.
+type RateLimitStats struct {
+	// The number of requests that passed through the limiter.
+	Requests int64
+
+	// The number of requests that had to wait before being sent.
+	Delayed int64
+
+	// The number of requests abandoned while waiting because their context ended.
+	Canceled int64
+
+	// The total and longest time requests spent waiting.
+	TotalWait time.Duration
+	MaxWait   time.Duration
+}
+
+// WithRateLimit configures the client to send at most requests per interval, allowing bursts of up to burst
+// requests. The limit is shared by every request of the client, including those of every AuthorizedUser created from
+// it. Requests wait for their turn until their context ends. If requests or interval is not positive the option is
+// rejected and every request of the client fails with ErrInvalidRateLimit.
+func WithRateLimit(requests int, interval time.Duration, burst int) ClientOption {
+	return func(c *Client) {
+		if c.rateLimiter == nil {
+			c.rateLimiter = newRateLimiter()
+		}
+
+		b, err := newTokenBucket(requests, interval, burst)
+		if err != nil {
+			c.rateLimiter.err = err
+			return
+		}
+		c.rateLimiter.global = b
+	}
+}
+
+// WithPerUserRateLimit configures the client to additionally limit the requests made for the same Withings user to
+// at most requests per interval, allowing bursts of up to burst requests. This prevents a single user from
+// consuming the whole budget of the client. Requests made with a token without a user ID are limited per access token.
+// If requests or interval is not positive the option is rejected and every request of the client fails with
+// ErrInvalidRateLimit.
+func WithPerUserRateLimit(requests int, interval time.Duration, burst int) ClientOption {
+	return func(c *Client) {
+		if c.rateLimiter == nil {
+			c.rateLimiter = newRateLimiter()
+		}
+
+		if _, err := newTokenBucket(requests, interval, burst); err != nil {
+			c.rateLimiter.err = err
+			return
+		}
+		c.rateLimiter.perUser = func() *tokenBucket {
+			// The parameters were validated when the option was applied.
+			b, _ := newTokenBucket(requests, interval, burst)
+			return b
+		}
+	}
+}
+
+// RateLimitStats returns the statistics of the client side rate limiter. The statistics are empty if the client
+// was not configured with a rate limit.
+func (c *Client) RateLimitStats() RateLimitStats {
+	if c.rateLimiter == nil {
+		return RateLimitStats{}
+	}
+
+	c.rateLimiter.mu.Lock()
+	defer c.rateLimiter.mu.Unlock()
+
+	return c.rateLimiter.stats
+}
+
+// rateLimiter limits the requests of a client with a global bucket and optional per user buckets.
+type rateLimiter struct {
+	global  *tokenBucket
+	perUser func() *tokenBucket
+
+	// The error of a rejected rate limit option. Every request fails with it when set.
+	err error
+
+	mu        sync.Mutex
+	users     map[string]*tokenBucket
+	lastSweep time.Time
+	stats     RateLimitStats
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{
+		users: make(map[string]*tokenBucket),
+	}
+}
+
+// wait blocks until the request of the user identified by userKey may be sent or ctx ends. An empty userKey only
+// waits on the global limit. The time spent waiting is returned.
+func (r *rateLimiter) wait(ctx context.Context, userKey string) (time.Duration, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	buckets := make([]*tokenBucket, 0, 2)
+	if r.global != nil {
+		buckets = append(buckets, r.global)
+	}
+	if b := r.userBucket(userKey); b != nil {
+		buckets = append(buckets, b)
+	}
+
+	// Reserving from every bucket up front so the request waits for the slowest bucket only once.
+	now := time.Now()
+	var delay time.Duration
+	for _, b := range buckets {
+		if d := b.reserve(now); d > delay {
+			delay = d
+		}
+	}
+
+	err := sleep(ctx, delay)
+	if err != nil {
+		for _, b := range buckets {
+			b.release()
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stats.Requests++
+	if err != nil {
+		r.stats.Canceled++
+		return 0, fmt.Errorf("failed waiting for rate limit: %w", err)
+	}
+	if delay > 0 {
+		r.stats.Delayed++
+		r.stats.TotalWait += delay
+		if delay > r.stats.MaxWait {
+			r.stats.MaxWait = delay
+		}
+	}
+
+	return delay, nil
+}
+
+// middleware returns a middleware waiting on the limiter before every attempt of a call.
+func (r *rateLimiter) middleware() Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(call *Call) (*CallResponse, error) {
+			waited, err := r.wait(call.Context(), userKey(call))
+			if err != nil {
+				return nil, err
+			}
+			call.RateLimitWait += waited
+
+			return next(call)
+		}
+	}
+}
+
+// userKey returns the key of the per user bucket of the call. Calls are keyed by the Withings user ID so refreshing
+// the token of a user keeps its bucket. Calls without a user ID are keyed by a hash of their access token so the
+// token itself is not retained. An empty key is returned for calls without an access token.
+func userKey(call *Call) string {
+	if call.UserID != "" {
+		return "user:" + call.UserID
+	}
+
+	auth := call.Request.Header.Get("Authorization")
+	if auth == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(auth))
+	return "token:" + hex.EncodeToString(sum[:])
+}
+
+// userBucket returns the bucket of the user, creating it if needed. Nil is returned if there is no per user limit.
+func (r *rateLimiter) userBucket(userKey string) *tokenBucket {
+	if r.perUser == nil || userKey == "" {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Discarding the buckets of users that have been idle long enough for them to refill. A refilled bucket is the
+	// same as a new one so no limit is lost.
+	now := time.Now()
+	if now.Sub(r.lastSweep) >= userBucketSweepInterval {
+		for k, v := range r.users {
+			if v.full(now) {
+				delete(r.users, k)
+			}
+		}
+		r.lastSweep = now
+	}
+
+	b, ok := r.users[userKey]
+	if !ok {
+		b = r.perUser()
+		r.users[userKey] = b
+	}
+
+	return b
+}
+
+// tokenBucket is a token bucket that refills continuously at a fixed rate.
+type tokenBucket struct {
+	mu sync.Mutex
+
+	// The number of tokens added per second and the capacity of the bucket.
+	rate  float64
+	burst float64
+
+	// The tokens available as of last. Tokens may be negative when requests have reserved future tokens.
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket returns a full bucket allowing requests per interval with bursts of up to burst. A burst less than
+// one is treated as one. ErrInvalidRateLimit is returned if requests or interval is not positive.
+func newTokenBucket(requests int, interval time.Duration, burst int) (*tokenBucket, error) {
+	if requests <= 0 || interval <= 0 {
+		return nil, fmt.Errorf("%w: %d requests per %s", ErrInvalidRateLimit, requests, interval)
+	}
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &tokenBucket{
+		rate:   float64(requests) / interval.Seconds(),
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}, nil
+}
+
+// refill adds the tokens accumulated since the last refill. The caller must hold b.mu.
+func (b *tokenBucket) refill(now time.Time) {
+	if now.After(b.last) {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+	}
+}
+
+// reserve takes a token from the bucket and returns how long the caller must wait before the token is available.
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(now)
+	b.tokens--
+	if b.tokens >= 0 || b.rate <= 0 {
+		return 0
+	}
+
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// release returns a reserved token that was not used.
+func (b *tokenBucket) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens++
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+}
+
+// full reports whether the bucket has refilled completely.
+func (b *tokenBucket) full(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(now)
+	return b.tokens >= b.burst
+}
+
+// sleep blocks for the delay provided or until ctx ends, in which case the error of ctx is returned.
+func sleep(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,106 @@
+package withings_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jrmycanady/withings"
+	"github.com/jrmycanady/withings/withingstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_WithRateLimit_Option(t *testing.T) {
+	t.Parallel()
+
+	srv := withingstest.NewServer()
+	defer srv.Close()
+	srv.AddUser(withingstest.NewDemoUser("1", time.Now()))
+	srv.AddUser(withingstest.NewDemoUser("2", time.Now()))
+	c := srv.NewClient(url.URL{}, withings.WithRateLimit(20, time.Second, 1))
+
+	// Users created from the same client share the limit.
+	users := []*withings.AuthorizedUser{
+		c.NewAuthorizedUser(srv.IssueToken("1")),
+		c.NewAuthorizedUser(srv.IssueToken("2")),
+	}
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		_, _, err := users[i%2].GetMeasure(context.Background(), withings.GetMeasureParam{})
+		require.Nil(t, err)
+	}
+
+	assert.GreaterOrEqual(t, time.Since(start), 140*time.Millisecond)
+	stats := c.RateLimitStats()
+	assert.Equal(t, int64(4), stats.Requests)
+	assert.Equal(t, int64(3), stats.Delayed)
+	assert.Greater(t, stats.TotalWait, time.Duration(0))
+}
+
+func TestClient_WithPerUserRateLimit_Option(t *testing.T) {
+	t.Parallel()
+
+	srv := withingstest.NewServer()
+	defer srv.Close()
+	srv.AddUser(withingstest.NewDemoUser("1", time.Now()))
+	srv.AddUser(withingstest.NewDemoUser("2", time.Now()))
+	c := srv.NewClient(url.URL{}, withings.WithPerUserRateLimit(1, time.Minute, 1))
+
+	token := srv.IssueToken("1")
+	_, err := c.GetUserDevice(context.Background(), token)
+	require.Nil(t, err)
+
+	// Another user is not affected by the first user exhausting their limit.
+	_, err = c.GetUserDevice(context.Background(), srv.IssueToken("2"))
+	require.Nil(t, err)
+
+	// The first user must wait a minute which exceeds the deadline.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = c.GetUserDevice(ctx, token)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Equal(t, int64(1), c.RateLimitStats().Canceled)
+
+	// A new token for the first user shares the limit of the user.
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = c.GetUserDevice(ctx, srv.IssueToken("1"))
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Equal(t, int64(2), c.RateLimitStats().Canceled)
+}
+
+func TestClient_WithRateLimit_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		opt  withings.ClientOption
+	}{
+		{name: "Zero requests", opt: withings.WithRateLimit(0, time.Second, 1)},
+		{name: "Negative requests", opt: withings.WithRateLimit(-1, time.Second, 1)},
+		{name: "Zero interval", opt: withings.WithRateLimit(1, 0, 1)},
+		{name: "Negative interval", opt: withings.WithRateLimit(1, -time.Second, 1)},
+		{name: "Zero per user requests", opt: withings.WithPerUserRateLimit(0, time.Second, 1)},
+		{name: "Zero per user interval", opt: withings.WithPerUserRateLimit(1, 0, 1)},
+	}
+
+	srv := withingstest.NewServer()
+	defer srv.Close()
+	srv.AddUser(withingstest.NewDemoUser("1", time.Now()))
+	token := srv.IssueToken("1")
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := srv.NewClient(url.URL{}, tt.opt)
+			_, err := c.GetUserDevice(context.Background(), token)
+			assert.True(t, errors.Is(err, withings.ErrInvalidRateLimit))
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package withings
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultRefreshAhead is how long before it expires the token of an active user is refreshed by default.
+	DefaultRefreshAhead = 15 * time.Minute
+
+	// DefaultKeepAlive is how long after it was obtained the token of an idle user is refreshed by default. Refresh
+	// tokens expire after about a year without use.
+	DefaultKeepAlive = 30 * 24 * time.Hour
+
+	// DefaultRefreshCheckInterval is how often a Refresher checks the tokens of its users by default.
+	DefaultRefreshCheckInterval = time.Minute
+)
+
+// ErrUnknownUser is returned when a user is registered without a Withings user ID.
+var ErrUnknownUser = errors.New("user id of token is unknown")
+
+// RefresherOptions configures a Refresher. The zero value uses the defaults.
+type RefresherOptions struct {
+	// How long before it expires the token of an active user is refreshed, so requests never wait for a refresh. A
+	// user is active if its token was used since it was obtained. If zero DefaultRefreshAhead is used.
+	RefreshAhead time.Duration
+
+	// How long after it was obtained the token of an idle user is refreshed, so its refresh token does not expire.
+	// If zero DefaultKeepAlive is used.
+	KeepAlive time.Duration
+
+	// The fraction of RefreshAhead and KeepAlive that is randomized to spread out the refreshes of users whose
+	// tokens were obtained at the same time. It must be between 0 and 1.
+	Jitter float64
+
+	// How often the tokens of the users are checked. If zero DefaultRefreshCheckInterval is used.
+	CheckInterval time.Duration
+
+	// Called with every user whose refresh failed permanently, for example because access was revoked. The user is
+	// no longer refreshed unless registered again.
+	OnFailure func(userID string, err error)
+}
+
+// Refresher refreshes the tokens of a set of users in the background. Active users have their token refreshed ahead
+// of expiry and idle users have their refresh token exercised periodically to keep it alive. Refreshed tokens are
+// handled as by the AuthorizedUser itself, they are saved to its TokenStore and passed to its OnTokenRefresh callback.
+type Refresher struct {
+	opts RefresherOptions
+
+	mu     sync.Mutex
+	users  map[string]*refresherUser
+	failed map[string]error
+}
+
+// refresherUser is a user registered with a Refresher.
+type refresherUser struct {
+	u *AuthorizedUser
+
+	// The access token the jitter was drawn for. A new jitter is drawn for every token.
+	accessToken string
+	jitter      float64
+}
+
+// NewRefresher returns a refresher without any users. The refresher does nothing until Run is called.
+func NewRefresher(opts RefresherOptions) *Refresher {
+	if opts.RefreshAhead <= 0 {
+		opts.RefreshAhead = DefaultRefreshAhead
+	}
+	if opts.KeepAlive <= 0 {
+		opts.KeepAlive = DefaultKeepAlive
+	}
+	if opts.CheckInterval <= 0 {
+		opts.CheckInterval = DefaultRefreshCheckInterval
+	}
+
+	return &Refresher{
+		opts:   opts,
+		users:  make(map[string]*refresherUser),
+		failed: make(map[string]error),
+	}
+}
+
+// Register adds the user to the users refreshed, replacing any user registered with the same Withings user ID and
+// clearing any failure of the user. ErrUnknownUser is returned if the user ID of the user is not known.
+func (r *Refresher) Register(u *AuthorizedUser) error {
+	if u.userID == "" {
+		return ErrUnknownUser
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.users[u.userID] = &refresherUser{u: u}
+	delete(r.failed, u.userID)
+	return nil
+}
+
+// Unregister removes the user with the Withings user ID provided from the users refreshed.
+func (r *Refresher) Unregister(userID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.users, userID)
+	delete(r.failed, userID)
+}
+
+// Failed returns the users whose refresh failed permanently keyed by Withings user ID along with the error of the
+// refresh.
+func (r *Refresher) Failed() map[string]error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	failed := make(map[string]error, len(r.failed))
+	for userID, err := range r.failed {
+		failed[userID] = err
+	}
+	return failed
+}
+
+// Run refreshes the tokens of the users as they become due until ctx ends. Run waits for any refresh in progress to
+// be abandoned before returning the error of ctx.
+func (r *Refresher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.opts.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		r.refreshDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// refreshDue refreshes the token of every user that is due, one user at a time.
+func (r *Refresher) refreshDue(ctx context.Context) {
+	r.mu.Lock()
+	users := make([]*refresherUser, 0, len(r.users))
+	for _, ru := range r.users {
+		users = append(users, ru)
+	}
+	r.mu.Unlock()
+
+	now := time.Now()
+	for _, ru := range users {
+		if ctx.Err() != nil {
+			return
+		}
+
+		t, lastUsed := ru.u.usage()
+		if !r.due(ru, t, lastUsed, now) {
+			continue
+		}
+
+		// Only the token observed is refreshed. A newer token obtained in the meantime is kept.
+		_, err := ru.u.refresh(ctx, t.ExpiresAt)
+		if err == nil || ctx.Err() != nil || !permanent(err) {
+			continue
+		}
+
+		r.mu.Lock()
+		if r.users[ru.u.userID] == ru {
+			delete(r.users, ru.u.userID)
+			r.failed[ru.u.userID] = err
+		}
+		r.mu.Unlock()
+
+		if r.opts.OnFailure != nil {
+			r.opts.OnFailure(ru.u.userID, err)
+		}
+	}
+}
+
+// due reports whether the token t of the user, last used at lastUsed, should be refreshed at now.
+func (r *Refresher) due(ru *refresherUser, t AccessToken, lastUsed time.Time, now time.Time) bool {
+	if ru.accessToken != t.AccessToken {
+		ru.accessToken = t.AccessToken
+		ru.jitter = r.opts.Jitter * rand.Float64()
+	}
+
+	obtained := t.ExpiresAt.Add(-time.Duration(t.ExpiresIn) * time.Second)
+	if lastUsed.After(obtained) {
+		ahead := r.opts.RefreshAhead + time.Duration(ru.jitter*float64(r.opts.RefreshAhead))
+		return !now.Before(t.ExpiresAt.Add(-ahead))
+	}
+
+	keepAlive := r.opts.KeepAlive - time.Duration(ru.jitter*float64(r.opts.KeepAlive))
+	return !now.Before(obtained.Add(keepAlive))
+}
+
+// permanent reports whether a failed refresh will not succeed if tried again. Errors returned by the API that are not
+// temporary are permanent, as is the revocation of the access of the user.
+func permanent(err error) bool {
+	if errors.Is(err, ErrRevoked) {
+		return true
+	}
+
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && !errors.Is(err, ErrRetryable)
+}
@@ -0,0 +1,153 @@
+package withings_test
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jrmycanady/withings"
+	"github.com/jrmycanady/withings/withingstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runRefresher runs the refresher until the test ends.
+func runRefresher(t *testing.T, r *withings.Refresher) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- r.Run(ctx)
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		assert.ErrorIs(t, <-done, context.Canceled)
+	})
+}
+
+func TestRefresher(t *testing.T) {
+	t.Parallel()
+
+	srv := withingstest.NewServer(withingstest.WithTokenLifetime(time.Hour))
+	defer srv.Close()
+	srv.AddUser(withingstest.NewDemoUser("1", time.Now()))
+	srv.AddUser(withingstest.NewDemoUser("2", time.Now()))
+	srv.AddUser(withingstest.NewDemoUser("3", time.Now()))
+	srv.AddUser(withingstest.NewDemoUser("4", time.Now()))
+	c := srv.NewClient(url.URL{})
+	ctx := context.Background()
+
+	t.Run("Refreshes active users ahead of expiry", func(t *testing.T) {
+		var mu sync.Mutex
+		var refreshed []withings.AccessToken
+		token := srv.IssueToken("1")
+		u := c.NewAuthorizedUser(token, withings.OnTokenRefresh(func(old withings.AccessToken, new withings.AccessToken) {
+			mu.Lock()
+			defer mu.Unlock()
+			refreshed = append(refreshed, new)
+		}))
+		_, err := u.API().GetMeasure(ctx, withings.GetMeasureParam{})
+		require.Nil(t, err)
+
+		r := withings.NewRefresher(withings.RefresherOptions{
+			RefreshAhead:  2 * time.Hour,
+			Jitter:        0.5,
+			CheckInterval: time.Millisecond,
+		})
+		require.Nil(t, r.Register(u))
+		runRefresher(t, r)
+
+		require.Eventually(t, func() bool {
+			return u.Token().AccessToken != token.AccessToken
+		}, time.Second, time.Millisecond)
+
+		// The refreshed token has not been used so the user is idle until the token is used again.
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		require.Len(t, refreshed, 1)
+		assert.Equal(t, refreshed[0], u.Token())
+	})
+
+	t.Run("Keeps idle users alive", func(t *testing.T) {
+		store := withings.NewMemoryTokenStore()
+		token := srv.IssueToken("2")
+		u := c.NewAuthorizedUser(token, withings.WithTokenStore(store, "2"))
+
+		r := withings.NewRefresher(withings.RefresherOptions{
+			KeepAlive:     10 * time.Millisecond,
+			CheckInterval: time.Millisecond,
+		})
+		require.Nil(t, r.Register(u))
+		runRefresher(t, r)
+
+		require.Eventually(t, func() bool {
+			stored, err := store.Load(ctx, "2")
+			return err == nil && stored.AccessToken != token.AccessToken
+		}, time.Second, time.Millisecond)
+		assert.Empty(t, r.Failed())
+	})
+
+	t.Run("Reports permanent failures", func(t *testing.T) {
+		u := c.NewAuthorizedUser(srv.IssueToken("3"))
+		srv.Fail(withingstest.Failure{Path: withings.PathOAuth2, Status: withingstest.StatusInvalidParams})
+
+		failures := make(chan string, 1)
+		r := withings.NewRefresher(withings.RefresherOptions{
+			KeepAlive:     time.Nanosecond,
+			CheckInterval: time.Millisecond,
+			OnFailure: func(userID string, err error) {
+				assert.ErrorIs(t, err, withings.ErrInvalidParams)
+				failures <- userID
+			},
+		})
+		require.Nil(t, r.Register(u))
+		runRefresher(t, r)
+
+		select {
+		case userID := <-failures:
+			assert.Equal(t, "3", userID)
+		case <-time.After(time.Second):
+			require.Fail(t, "refresh did not fail")
+		}
+
+		failed := r.Failed()
+		require.Len(t, failed, 1)
+		assert.ErrorIs(t, failed["3"], withings.ErrInvalidParams)
+	})
+
+	t.Run("Reports revoked users", func(t *testing.T) {
+		u := c.NewAuthorizedUser(srv.IssueToken("4"))
+		require.Nil(t, u.Revoke(ctx))
+
+		failures := make(chan error, 1)
+		r := withings.NewRefresher(withings.RefresherOptions{
+			RefreshAhead:  2 * time.Hour,
+			KeepAlive:     time.Nanosecond,
+			CheckInterval: time.Millisecond,
+			OnFailure: func(userID string, err error) {
+				failures <- err
+			},
+		})
+		require.Nil(t, r.Register(u))
+		runRefresher(t, r)
+
+		select {
+		case err := <-failures:
+			assert.ErrorIs(t, err, withings.ErrRevoked)
+		case <-time.After(time.Second):
+			require.Fail(t, "refresh did not fail")
+		}
+		assert.ErrorIs(t, r.Failed()["4"], withings.ErrRevoked)
+	})
+
+	t.Run("Requires a user ID", func(t *testing.T) {
+		token := srv.IssueToken("1")
+		token.UserID = ""
+
+		r := withings.NewRefresher(withings.RefresherOptions{})
+		assert.ErrorIs(t, r.Register(c.NewAuthorizedUser(token)), withings.ErrUnknownUser)
+	})
+}
@@ -0,0 +1,138 @@
+package withings
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// apiResponse is the portion of every API response needed to determine the outcome of a request.
+type apiResponse struct {
+	Status   int64  `json:"status"`
+	APIError string `json:"error"`
+}
+
+// do sends the request through the middleware chain of the client and decodes the body of the response into v. The
+// error is nil if the API responded, even with a non zero status, so the caller can inspect the status of the
+// response decoded into v.
+func (c *Client) do(req *http.Request, v interface{}) error {
+	call := &Call{
+		Request:  req,
+		Action:   actionOf(req),
+		Endpoint: endpointOf(req.URL),
+		UserID:   userIDFrom(req.Context()),
+		Attempt:  1,
+	}
+
+	resp, err := c.roundTripper()(call)
+	if err == nil {
+		if err = json.Unmarshal(resp.Body, v); err != nil {
+			return fmt.Errorf("failed to parse response: %w", err)
+		}
+		return nil
+	}
+
+	// The API responded with an error status so the response is still decoded for the caller to inspect. Any other
+	// error, such as one returned by a middleware along with the response, is returned as is.
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && resp != nil && resp.Body != nil {
+		if jsonErr := json.Unmarshal(resp.Body, v); jsonErr == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// send performs a single attempt of the call. The response is returned if the API responded. Its body is set
+// whenever the API responded with a parsable status. The error is an *APIError if the status was non zero.
+func (c *Client) send(call *Call) (*CallResponse, error) {
+	req := call.Request
+
+	// Each attempt requires a fresh copy of the request body. A signed request is signed again for every retry as the
+	// nonce of the previous attempt has been used up.
+	attempt := req.Clone(req.Context())
+	if sign := signerFrom(req.Context()); sign != nil && call.Attempt > 1 {
+		form, err := sign()
+		if err != nil {
+			return nil, err
+		}
+		body := form.Encode()
+		attempt.Body = io.NopCloser(strings.NewReader(body))
+		attempt.ContentLength = int64(len(body))
+	} else if req.GetBody != nil {
+		b, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("failed to copy body of request: %w", err)
+		}
+		attempt.Body = b
+	}
+
+	resp, err := c.HttpClient.Do(attempt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return &CallResponse{HTTPStatus: resp.StatusCode}, fmt.Errorf("failed to read body of request: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return &CallResponse{HTTPStatus: resp.StatusCode}, &APIError{
+			HTTPStatus: resp.StatusCode,
+			Action:     call.Action,
+			Endpoint:   call.Endpoint,
+		}
+	}
+
+	var status apiResponse
+	if err = json.Unmarshal(body, &status); err != nil {
+		return &CallResponse{HTTPStatus: resp.StatusCode}, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	callResp := &CallResponse{
+		HTTPStatus: resp.StatusCode,
+		Status:     status.Status,
+		Body:       body,
+	}
+	if status.Status != StatusOK {
+		return callResp, newAPIError(req, status.Status, status.APIError)
+	}
+
+	return callResp, nil
+}
+
+// actionOf returns the action of the request. The action is taken from the query or, for form requests, the body.
+func actionOf(req *http.Request) string {
+	if action := req.URL.Query().Get("action"); action != "" {
+		return action
+	}
+	return formOf(req).Get("action")
+}
+
+// formOf returns the form values in the body of the request without consuming the body. Nil is returned if the
+// request has no body that can be read again.
+func formOf(req *http.Request) url.Values {
+	if req.GetBody == nil {
+		return nil
+	}
+
+	b, err := req.GetBody()
+	if err != nil {
+		return nil
+	}
+	defer b.Close()
+	form, err := io.ReadAll(b)
+	if err != nil {
+		return nil
+	}
+	values, err := url.ParseQuery(string(form))
+	if err != nil {
+		return nil
+	}
+	return values
+}
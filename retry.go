@@ -0,0 +1,126 @@
+package withings
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"time"
+)
+
+// RetryPolicy configures how requests that fail with a temporary error are retried. The zero value disables
+// retrying.
+type RetryPolicy struct {
+	// The maximum number of attempts made for a request, including the first. Values less than 2 disable retrying.
+	MaxAttempts int
+
+	// The delay before the first retry. The delay doubles with every following retry.
+	InitialBackoff time.Duration
+
+	// The maximum delay between two attempts. If zero the delay is not capped.
+	MaxBackoff time.Duration
+
+	// The fraction of each delay that is randomized to spread out retries of concurrent requests. It must be
+	// between 0 and 1.
+	Jitter float64
+
+	// The errors that cause a request to be retried, matched with errors.Is. If empty ErrRetryable is used which
+	// matches rate limiting, timeouts, unknown API errors and HTTP 5xx responses. Network errors of the HTTP client
+	// are retried if ErrRetryable is included, any other error is returned immediately.
+	RetryOn []error
+}
+
+// DefaultRetryPolicy returns a policy that makes up to four attempts with a backoff starting at half a second.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    4,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     10 * time.Second,
+		Jitter:         0.2,
+		RetryOn:        []error{ErrRetryable},
+	}
+}
+
+// WithRetryPolicy configures the client to retry requests according to the policy provided. The policy applies to
+// every API request, including token requests.
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(c *Client) {
+		c.retryPolicy = policy
+	}
+}
+
+// RetryMiddleware returns a middleware retrying calls according to the policy provided. The attempt of the call is
+// updated before every attempt. WithRetryPolicy installs this middleware inside those added with WithMiddleware.
+func RetryMiddleware(policy RetryPolicy) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(call *Call) (*CallResponse, error) {
+			attempt := call.Attempt
+			if attempt < 1 {
+				attempt = 1
+			}
+
+			for ; ; attempt++ {
+				call.Attempt = attempt
+
+				resp, err := next(call)
+				if err == nil || !policy.shouldRetry(attempt, err) || !wait(call.Context(), policy.backoff(attempt)) {
+					return resp, err
+				}
+			}
+		}
+	}
+}
+
+// shouldRetry reports whether a request that failed with err on the attempt provided should be retried.
+func (p *RetryPolicy) shouldRetry(attempt int, err error) bool {
+	if attempt >= p.MaxAttempts || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	retryOn := p.RetryOn
+	if len(retryOn) == 0 {
+		retryOn = []error{ErrRetryable}
+	}
+
+	// Only failures of the HTTP client to reach the API are network failures, which are always temporary. Errors of
+	// the client itself, such as a misconfiguration, would fail every attempt.
+	var netErr net.Error
+	isNetErr := errors.As(err, &netErr)
+	for _, target := range retryOn {
+		if errors.Is(err, target) {
+			return true
+		}
+		if isNetErr && target == ErrRetryable {
+			return true
+		}
+	}
+
+	return false
+}
+
+// backoff returns the delay before the attempt following the attempt provided.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.InitialBackoff
+	for i := 1; i < attempt && (p.MaxBackoff <= 0 || delay < p.MaxBackoff); i++ {
+		delay *= 2
+	}
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+
+	if p.Jitter > 0 {
+		delay -= time.Duration(p.Jitter * rand.Float64() * float64(delay))
+	}
+
+	return delay
+}
+
+// wait blocks for the delay provided or until ctx is done. It returns false without waiting if the deadline of ctx
+// would pass before the delay ends.
+func wait(ctx context.Context, delay time.Duration) bool {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+		return false
+	}
+
+	return sleep(ctx, delay) == nil
+}
@@ -0,0 +1,149 @@
+package withings_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jrmycanady/withings"
+	"github.com/jrmycanady/withings/withingstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_WithRetryPolicy_Option(t *testing.T) {
+	t.Parallel()
+
+	policy := withings.RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     5 * time.Millisecond,
+		Jitter:         0.5,
+	}
+
+	tests := map[string]struct {
+		failure          withingstest.Failure
+		expectedErr      error
+		expectedRequests int
+	}{
+		"Retries rate limiting until success": {
+			failure:          withingstest.Failure{Status: withings.StatusTooManyRequests, Times: 2},
+			expectedRequests: 3,
+		},
+		"Retries HTTP server errors until success": {
+			failure:          withingstest.Failure{HTTPStatus: http.StatusBadGateway, Times: 2},
+			expectedRequests: 3,
+		},
+		"Gives up after max attempts": {
+			failure:          withingstest.Failure{Status: withings.StatusTooManyRequests, Times: 3},
+			expectedErr:      withings.ErrRateLimited,
+			expectedRequests: 3,
+		},
+		"Does not retry invalid params": {
+			failure:          withingstest.Failure{Status: withings.StatusInvalidParams},
+			expectedErr:      withings.ErrInvalidParams,
+			expectedRequests: 1,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := withingstest.NewServer()
+			defer srv.Close()
+			srv.AddUser(withingstest.NewDemoUser("1", time.Now()))
+			c := srv.NewClient(url.URL{}, withings.WithRetryPolicy(policy))
+
+			test.failure.Path = withings.PathMeasureV2
+			srv.Fail(test.failure)
+
+			_, err := c.GetWorkout(context.Background(), srv.IssueToken("1"), withings.GetWorkoutParam{})
+			switch test.expectedErr {
+			case nil:
+				require.Nil(t, err)
+			default:
+				assert.True(t, errors.Is(err, test.expectedErr))
+			}
+			assert.Equal(t, test.expectedRequests, srv.RequestCount(withings.PathMeasureV2, withings.APIActionGetWorkout))
+		})
+	}
+}
+
+func TestClient_WithRetryPolicy_RefreshAccessToken(t *testing.T) {
+	t.Parallel()
+
+	srv := withingstest.NewServer()
+	defer srv.Close()
+	srv.AddUser(withingstest.NewDemoUser("1", time.Now()))
+	c := srv.NewClient(url.URL{}, withings.WithRetryPolicy(withings.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}))
+
+	srv.Fail(withingstest.Failure{Path: withings.PathOAuth2, HTTPStatus: http.StatusServiceUnavailable})
+
+	resp, err := c.RefreshAccessToken(srv.IssueToken("1"))
+	require.Nil(t, err)
+	assert.NotEmpty(t, resp.AccessToken.AccessToken)
+	assert.Equal(t, 2, srv.RequestCount(withings.PathOAuth2, ""))
+}
+
+func TestClient_WithRetryPolicy_ContextDeadline(t *testing.T) {
+	t.Parallel()
+
+	srv := withingstest.NewServer()
+	defer srv.Close()
+	srv.AddUser(withingstest.NewDemoUser("1", time.Now()))
+	c := srv.NewClient(url.URL{}, withings.WithRetryPolicy(withings.RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Minute}))
+
+	srv.Fail(withingstest.Failure{Status: withings.StatusTooManyRequests, Times: 5})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	start := time.Now()
+	_, err := c.GetMeasure(ctx, srv.IssueToken("1"), withings.GetMeasureParam{})
+	assert.True(t, errors.Is(err, withings.ErrRateLimited))
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, 1, srv.RequestCount(withings.PathMeasure, ""))
+}
+
+func TestClient_WithRetryPolicy_Errors(t *testing.T) {
+	t.Parallel()
+
+	srv := withingstest.NewServer()
+	defer srv.Close()
+	srv.AddUser(withingstest.NewDemoUser("1", time.Now()))
+	policy := withings.WithRetryPolicy(withings.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond})
+
+	t.Run("Retries network errors", func(t *testing.T) {
+		c := srv.NewClient(url.URL{}, policy)
+		transport := c.HttpClient.Transport
+		if transport == nil {
+			transport = http.DefaultTransport
+		}
+		var attempts int
+		c.HttpClient.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			attempts++
+			if attempts == 1 {
+				return nil, errors.New("connection reset")
+			}
+			return transport.RoundTrip(req)
+		})
+
+		_, err := c.GetMeasure(context.Background(), srv.IssueToken("1"), withings.GetMeasureParam{})
+		require.Nil(t, err)
+		assert.Equal(t, 2, attempts)
+	})
+
+	t.Run("Does not retry client errors", func(t *testing.T) {
+		logger := &testLogger{}
+		c := srv.NewClient(url.URL{}, policy, withings.WithLogger(logger), withings.WithRateLimit(0, time.Second, 1))
+
+		_, err := c.GetMeasure(context.Background(), srv.IssueToken("1"), withings.GetMeasureParam{})
+		assert.True(t, errors.Is(err, withings.ErrInvalidRateLimit))
+		assert.Len(t, logger.events, 1)
+	})
+}
@@ -0,0 +1,47 @@
+package withings
+
+import (
+	"context"
+	"errors"
+	"net/url"
+)
+
+// ErrRevoked is returned by the methods of an AuthorizedUser whose access has been revoked with Revoke.
+var ErrRevoked = errors.New("access revoked")
+
+// RevokeUserResp is the response type returned by the Withings API when revoking the access to the data of a user.
+type RevokeUserResp struct {
+	Status   int64  `json:"status"`
+	APIError string `json:"error"`
+}
+
+// RevokeUser revokes the access of the client to the data of the user with the Withings user ID provided. Every token
+// issued to the client for the user is invalidated. The request is signed with a nonce retrieved from the signature
+// service. Error will be non nil upon an internal or api error. If the API returned the error the response will contain
+// the error.
+func (c *Client) RevokeUser(ctx context.Context, userID string) (*RevokeUserResp, error) {
+	if userID == "" {
+		return nil, ErrUnknownUser
+	}
+
+	formData := url.Values{}
+	formData.Set("userid", userID)
+
+	req, err := c.signedRequest(withUserID(ctx, userID), PathOAuth2, APIActionRevoke, formData)
+	if err != nil {
+		return nil, err
+	}
+
+	// Executing the request.
+	var mResp RevokeUserResp
+	if err = c.do(req, &mResp); err != nil {
+		return nil, err
+	}
+
+	switch mResp.Status {
+	case 0:
+		return &mResp, nil
+	default:
+		return &mResp, newAPIError(req, mResp.Status, mResp.APIError)
+	}
+}
@@ -0,0 +1,134 @@
+package withings_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jrmycanady/withings"
+	"github.com/jrmycanady/withings/withingstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_RevokeUser(t *testing.T) {
+	t.Parallel()
+
+	srv := withingstest.NewServer()
+	defer srv.Close()
+	srv.AddUser(withingstest.NewDemoUser("1", time.Now()))
+	c := srv.NewClient(url.URL{})
+	ctx := context.Background()
+
+	t.Run("Invalidates the tokens of the user", func(t *testing.T) {
+		token := srv.IssueToken("1")
+
+		_, err := c.RevokeUser(ctx, "1")
+		require.Nil(t, err)
+
+		_, err = c.GetMeasure(ctx, token, withings.GetMeasureParam{})
+		assert.ErrorIs(t, err, withings.ErrInvalidToken)
+		_, err = c.RefreshAccessTokenContext(ctx, token)
+		assert.ErrorIs(t, err, withings.ErrInvalidParams)
+	})
+
+	t.Run("Rejects unknown users", func(t *testing.T) {
+		_, err := c.RevokeUser(ctx, "2")
+		assert.ErrorIs(t, err, withings.ErrInvalidParams)
+
+		_, err = c.RevokeUser(ctx, "")
+		assert.ErrorIs(t, err, withings.ErrUnknownUser)
+	})
+
+	t.Run("Signs every attempt with a new nonce", func(t *testing.T) {
+		srv.AddUser(withingstest.NewDemoUser("3", time.Now()))
+		c := srv.NewClient(url.URL{}, withings.WithRetryPolicy(withings.RetryPolicy{
+			MaxAttempts:    2,
+			InitialBackoff: time.Millisecond,
+		}))
+		srv.Fail(withingstest.Failure{Path: withings.PathOAuth2, Action: withings.APIActionRevoke, Status: withingstest.StatusTooManyRequests})
+		nonces := srv.RequestCount(withings.PathSignatureV2, withings.APIActionGetNonce)
+
+		_, err := c.RevokeUser(ctx, "3")
+		require.Nil(t, err)
+		assert.Equal(t, nonces+2, srv.RequestCount(withings.PathSignatureV2, withings.APIActionGetNonce))
+	})
+
+	t.Run("Requires a valid signature", func(t *testing.T) {
+		c := withings.NewClient(withingstest.DefaultClientID, "wrong", url.URL{}, srv.ClientOptions()...)
+		_, err := c.RevokeUser(ctx, "1")
+		assert.ErrorIs(t, err, withings.ErrInvalidParams)
+	})
+}
+
+func TestAuthorizedUser_Revoke(t *testing.T) {
+	t.Parallel()
+
+	srv := withingstest.NewServer()
+	defer srv.Close()
+	c := srv.NewClient(url.URL{})
+	ctx := context.Background()
+
+	// subscribe returns a user subscribed to notifications of two categories with its token saved to store.
+	subscribe := func(t *testing.T, userID string, store withings.TokenStore) *withings.AuthorizedUser {
+		srv.AddUser(withingstest.NewDemoUser(userID, time.Now()))
+		token := srv.IssueToken(userID)
+		require.Nil(t, store.Save(ctx, userID, token))
+
+		u := c.NewAuthorizedUser(token, withings.WithTokenStore(store, userID))
+		for _, appli := range []int{1, 44} {
+			_, err := u.API().SubscribeToNotifications(ctx, withings.SubscribeToNotificationsParam{
+				Appli:       appli,
+				CallbackURL: url.URL{Scheme: "https", Host: "example.com", Path: "/notify"},
+			})
+			require.Nil(t, err)
+		}
+		require.Len(t, srv.User(userID).Notifications, 2)
+
+		return u
+	}
+
+	t.Run("Unlinks the user", func(t *testing.T) {
+		store := withings.NewMemoryTokenStore()
+		u := subscribe(t, "1", store)
+		token := u.Token()
+
+		require.Nil(t, u.Revoke(ctx))
+
+		assert.Empty(t, srv.User("1").Notifications)
+		_, err := store.Load(ctx, "1")
+		assert.ErrorIs(t, err, withings.ErrTokenNotFound)
+		_, err = c.GetMeasure(ctx, token, withings.GetMeasureParam{})
+		assert.ErrorIs(t, err, withings.ErrInvalidToken)
+
+		// The user can no longer be used while revoking again only deletes the token.
+		_, err = u.API().GetMeasure(ctx, withings.GetMeasureParam{})
+		assert.ErrorIs(t, err, withings.ErrRevoked)
+		require.Nil(t, store.Save(ctx, "1", token))
+		require.Nil(t, u.Revoke(ctx))
+		_, err = store.Load(ctx, "1")
+		assert.ErrorIs(t, err, withings.ErrTokenNotFound)
+	})
+
+	t.Run("Keeps access if a subscription is not revoked", func(t *testing.T) {
+		store := withings.NewMemoryTokenStore()
+		u := subscribe(t, "2", store)
+		srv.Fail(withingstest.Failure{
+			Path:   withings.PathNotify,
+			Action: withings.APIActionNotificationRevoke,
+			Status: withingstest.StatusUnknownError,
+		})
+
+		assert.NotNil(t, u.Revoke(ctx))
+
+		_, err := store.Load(ctx, "2")
+		assert.Nil(t, err)
+		_, err = u.API().GetMeasure(ctx, withings.GetMeasureParam{})
+		assert.Nil(t, err)
+
+		// The failure is used up so revoking again completes.
+		require.Nil(t, u.Revoke(ctx))
+		assert.Empty(t, srv.User("2").Notifications)
+	})
+}
@@ -0,0 +1,122 @@
+package withings
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GetNonceResp is the response type returned by the Withings API for a request for a nonce.
+type GetNonceResp struct {
+	Status   int64        `json:"status"`
+	APIError string       `json:"error"`
+	Body     GetNonceBody `json:"body"`
+}
+
+// GetNonceBody is the body of the response returned by the Withings API for a request for a nonce.
+type GetNonceBody struct {
+	Nonce string `json:"nonce"`
+}
+
+// GetNonce retrieves a nonce from the signature service. A nonce is required by every signed action of the API and
+// may only be used for a single request. The request for the nonce is itself signed with the client secret. Error will
+// be non nil upon an internal or api error. If the API returned the error the response will contain the error.
+func (c *Client) GetNonce(ctx context.Context) (*GetNonceResp, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	// Building required form data for the request.
+	formData := url.Values{}
+	formData.Set("action", APIActionGetNonce)
+	formData.Set("client_id", c.clientID)
+	formData.Set("timestamp", timestamp)
+	formData.Set("signature", c.sign(APIActionGetNonce, timestamp))
+
+	req, err := newFormRequest(ctx, c.apiURL(PathSignatureV2), formData)
+	if err != nil {
+		return nil, err
+	}
+
+	// Executing the request.
+	var mResp GetNonceResp
+	if err = c.do(req, &mResp); err != nil {
+		return nil, err
+	}
+
+	switch mResp.Status {
+	case 0:
+		return &mResp, nil
+	default:
+		return &mResp, newAPIError(req, mResp.Status, mResp.APIError)
+	}
+}
+
+// signerKey is the context key of the function signing the form data of a signed request.
+type signerKey struct{}
+
+// signerFrom returns the function signing the form data of the request with ctx, if it is a signed request.
+func signerFrom(ctx context.Context) func() (url.Values, error) {
+	sign, _ := ctx.Value(signerKey{}).(func() (url.Values, error))
+	return sign
+}
+
+// signedRequest builds a request for a signed action of the service at path. A nonce is retrieved from the signature
+// service and the action, client ID, nonce and signature are added to the form data provided. As the nonce may only
+// be used once a new request must be built for every call, and every retry of the request is signed again with a new
+// nonce, see send.
+func (c *Client) signedRequest(ctx context.Context, path string, action string, formData url.Values) (*http.Request, error) {
+	sign := func() (url.Values, error) {
+		nonce, err := c.GetNonce(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get nonce: %w", err)
+		}
+
+		signed := make(url.Values, len(formData)+4)
+		for k, v := range formData {
+			signed[k] = v
+		}
+		signed.Set("action", action)
+		signed.Set("client_id", c.clientID)
+		signed.Set("nonce", nonce.Body.Nonce)
+		signed.Set("signature", c.sign(action, nonce.Body.Nonce))
+		return signed, nil
+	}
+
+	signed, err := sign()
+	if err != nil {
+		return nil, err
+	}
+
+	return newFormRequest(context.WithValue(ctx, signerKey{}, sign), c.apiURL(path), signed)
+}
+
+// sign returns the signature of a request for the action provided. The value is the nonce of the request, or the
+// timestamp for the request of a nonce.
+func (c *Client) sign(action string, value string) string {
+	return signature(c.clientSecret, action, c.clientID, value)
+}
+
+// signature returns the hex encoded HMAC-SHA256, keyed with the secret, of the values joined with commas as required
+// by the Withings API.
+func signature(secret string, values ...string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strings.Join(values, ",")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// newFormRequest builds a POST request to the URL provided with the form data as the body.
+func newFormRequest(ctx context.Context, rawURL string, formData url.Values) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rawURL, strings.NewReader(formData.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build http request: %w", err)
+	}
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	return req, nil
+}
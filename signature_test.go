@@ -0,0 +1,127 @@
+package withings_test
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"testing"
+
+	"github.com/jrmycanady/withings"
+	"github.com/jrmycanady/withings/withingstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignature(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		secret string
+		values []string
+		want   string
+	}{
+		{
+			name:   "Nonce request",
+			secret: "secret",
+			values: []string{"getnonce", "client", "1600000000"},
+			want:   "a7dce226429448138f349fe5d5037fdedb569243af9db41598799a2ef09745b3",
+		},
+		{
+			name:   "Signed action",
+			secret: "withings-secret",
+			values: []string{"revoke", "abc123", "nonce-1"},
+			want:   "f1219cb65a2b2e21f90aa83be912f4d4a41fc01d53cf9952ac34bf9b15121807",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, withings.Signature(tt.secret, tt.values...))
+		})
+	}
+}
+
+func TestClient_Sign(t *testing.T) {
+	t.Parallel()
+
+	// The expected signatures are of the action, client ID and value joined in that order, such as
+	// "revoke,client-id,nonce-1".
+	tests := []struct {
+		name   string
+		action string
+		value  string
+		want   string
+	}{
+		{
+			name:   "Nonce",
+			action: "revoke",
+			value:  "nonce-1",
+			want:   "70ac25db3b4684d6600ea339fbfdb717bd136139ff8cc4582a5c9269075779c3",
+		},
+		{
+			name:   "Timestamp",
+			action: "getnonce",
+			value:  "1600000000",
+			want:   "2cd4a05e2f5e63252e9602e90af8f6974e585160f9a26f52b3891dda8bc523eb",
+		},
+	}
+
+	c := withings.NewClient("client-id", "client-secret", url.URL{})
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, c.Sign(tt.action, tt.value))
+		})
+	}
+}
+
+func TestClient_GetNonce(t *testing.T) {
+	t.Parallel()
+
+	srv := withingstest.NewServer()
+	defer srv.Close()
+	ctx := context.Background()
+
+	t.Run("Signs the request", func(t *testing.T) {
+		resp, err := srv.NewClient(url.URL{}).GetNonce(ctx)
+		require.Nil(t, err)
+		assert.NotEmpty(t, resp.Body.Nonce)
+	})
+
+	t.Run("Rejects the wrong secret", func(t *testing.T) {
+		c := withings.NewClient(withingstest.DefaultClientID, "wrong", url.URL{}, srv.ClientOptions()...)
+		_, err := c.GetNonce(ctx)
+		assert.ErrorIs(t, err, withings.ErrInvalidParams)
+	})
+}
+
+func TestClient_SignedRequest(t *testing.T) {
+	t.Parallel()
+
+	srv := withingstest.NewServer()
+	defer srv.Close()
+	c := srv.NewClient(url.URL{})
+
+	req, err := c.SignedRequest(context.Background(), withings.PathOAuth2, "revoke", url.Values{"userid": {"1"}})
+	require.Nil(t, err)
+
+	body, err := io.ReadAll(req.Body)
+	require.Nil(t, err)
+	form, err := url.ParseQuery(string(body))
+	require.Nil(t, err)
+
+	assert.Equal(t, "revoke", form.Get("action"))
+	assert.Equal(t, withingstest.DefaultClientID, form.Get("client_id"))
+	assert.Equal(t, "1", form.Get("userid"))
+	require.NotEmpty(t, form.Get("nonce"))
+	assert.Equal(t,
+		withings.Signature(withingstest.DefaultClientSecret, "revoke", withingstest.DefaultClientID, form.Get("nonce")),
+		form.Get("signature"),
+	)
+}
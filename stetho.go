@@ -0,0 +1,241 @@
+package withings
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// StethoRecording is a recording of a digital stethoscope, such as the BPM Core, as returned by the Withings API.
+type StethoRecording struct {
+	DeviceID  string `json:"deviceid"`
+	Model     int64  `json:"model"`
+	SignalID  int64  `json:"signalid"`
+	Timestamp int64  `json:"timestamp"`
+
+	// The valvular heart disease classification of the recording. See the Withings documentation for the values.
+	VHD int64 `json:"vhd"`
+}
+
+// StethoRecordings is a slice of StethoRecording as returned by the Withings API.
+type StethoRecordings []StethoRecording
+
+// GetStethoListResp is the response type returned by the Withings API for a request for stethoscope recordings.
+type GetStethoListResp struct {
+	Status   int64             `json:"status"`
+	APIError string            `json:"error"`
+	Body     GetStethoListBody `json:"body"`
+}
+
+// GetStethoListBody is the body of the response returned by the Withings API for a request for stethoscope recordings.
+type GetStethoListBody struct {
+	Series StethoRecordings `json:"series"`
+	More   bool             `json:"more"`
+	Offset int64            `json:"offset"`
+}
+
+// GetStethoListParam contains the parameters needed to request stethoscope recordings.
+type GetStethoListParam struct {
+
+	// The start of the window of recordings to retrieve.
+	StartDate *time.Time
+
+	// The end of the window of recordings to retrieve.
+	EndDate *time.Time
+
+	// An offset value used for paging. The API response will return more with a 1 value if there are more pages
+	// to retrieve. Along with this an offset value is provided. That value should be provided here on the next
+	// request. See the Withings documentation for more information.
+	Offset int64
+}
+
+// UpdateQuery updates the query provided with the parameters of this param.
+func (p *GetStethoListParam) UpdateQuery(q url.Values) url.Values {
+	// Constructing the query parameters based on the param provided.
+	q.Set("action", APIActionGetStethoList)
+	if p.Offset > 0 {
+		q.Set("offset", strconv.FormatInt(p.Offset, 10))
+	}
+
+	if p.StartDate != nil {
+		q.Set("startdate", strconv.FormatInt(p.StartDate.Unix(), 10))
+	}
+	if p.EndDate != nil {
+		q.Set("enddate", strconv.FormatInt(p.EndDate.Unix(), 10))
+	}
+
+	return q
+}
+
+// GetStethoList retrieves the stethoscope recordings for the user represented by the token. Error will be non nil upon
+// an internal or api error. If the API returned the error the response will contain the error.
+func (c *Client) GetStethoList(ctx context.Context, token AccessToken, param GetStethoListParam) (*GetStethoListResp, error) {
+
+	// Construct authorized request to request data from the API.
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.apiURL(PathStethoV2), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build http request: %w", err)
+	}
+	req = authorize(req, token)
+
+	// Updating the query with the parameters generated by the param provided.
+	req.URL.RawQuery = param.UpdateQuery(req.URL.Query()).Encode()
+
+	// Executing the request.
+	var mResp GetStethoListResp
+	if err = c.do(req, &mResp); err != nil {
+		return nil, err
+	}
+
+	switch mResp.Status {
+	case 0:
+		return &mResp, nil
+	default:
+		return &mResp, newAPIError(req, mResp.Status, mResp.APIError)
+	}
+}
+
+// StethoSignal is the audio signal of a stethoscope recording as returned by the Withings API.
+type StethoSignal struct {
+	// The audio samples of the recording.
+	Signal []int64 `json:"signal"`
+
+	// The sampling frequency of the signal in Hz.
+	Frequency int64 `json:"frequency"`
+
+	// The duration of the recording.
+	Duration int64 `json:"duration"`
+
+	Format      int64 `json:"format"`
+	Size        int64 `json:"size"`
+	Resolution  int64 `json:"resolution"`
+	Channel     int64 `json:"channel"`
+	DeviceModel int64 `json:"device_model"`
+
+	// The valvular heart disease classification of the recording. See the Withings documentation for the values.
+	VHD int64 `json:"vhd"`
+}
+
+// ErrNoSamplingFrequency is returned when writing a signal without a sampling frequency as a WAV file.
+var ErrNoSamplingFrequency = errors.New("signal has no sampling frequency")
+
+// WriteWAV writes the signal to w as a mono 16-bit PCM WAV file sampled at the frequency of the signal. Samples outside
+// the range of 16-bit PCM are clipped. ErrNoSamplingFrequency is returned if the signal has no frequency.
+func (s *StethoSignal) WriteWAV(w io.Writer) error {
+	if s.Frequency <= 0 {
+		return ErrNoSamplingFrequency
+	}
+
+	const (
+		channels      = 1
+		bitsPerSample = 16
+		blockAlign    = channels * bitsPerSample / 8
+	)
+	dataSize := uint32(len(s.Signal) * blockAlign)
+
+	header := struct {
+		RIFF          [4]byte
+		ChunkSize     uint32
+		WAVE          [4]byte
+		Fmt           [4]byte
+		FmtSize       uint32
+		AudioFormat   uint16
+		Channels      uint16
+		SampleRate    uint32
+		ByteRate      uint32
+		BlockAlign    uint16
+		BitsPerSample uint16
+		Data          [4]byte
+		DataSize      uint32
+	}{
+		RIFF:          [4]byte{'R', 'I', 'F', 'F'},
+		ChunkSize:     36 + dataSize,
+		WAVE:          [4]byte{'W', 'A', 'V', 'E'},
+		Fmt:           [4]byte{'f', 'm', 't', ' '},
+		FmtSize:       16,
+		AudioFormat:   1,
+		Channels:      channels,
+		SampleRate:    uint32(s.Frequency),
+		ByteRate:      uint32(s.Frequency) * blockAlign,
+		BlockAlign:    blockAlign,
+		BitsPerSample: bitsPerSample,
+		Data:          [4]byte{'d', 'a', 't', 'a'},
+		DataSize:      dataSize,
+	}
+	if err := binary.Write(w, binary.LittleEndian, header); err != nil {
+		return fmt.Errorf("failed to write wav header: %w", err)
+	}
+
+	samples := make([]int16, len(s.Signal))
+	for i, v := range s.Signal {
+		switch {
+		case v > math.MaxInt16:
+			samples[i] = math.MaxInt16
+		case v < math.MinInt16:
+			samples[i] = math.MinInt16
+		default:
+			samples[i] = int16(v)
+		}
+	}
+	if err := binary.Write(w, binary.LittleEndian, samples); err != nil {
+		return fmt.Errorf("failed to write wav samples: %w", err)
+	}
+
+	return nil
+}
+
+// GetStethoSignalResp is the response type returned by the Withings API for a request for a stethoscope signal.
+type GetStethoSignalResp struct {
+	Status   int64        `json:"status"`
+	APIError string       `json:"error"`
+	Body     StethoSignal `json:"body"`
+}
+
+// GetStethoSignalParam contains the parameters needed to request a stethoscope signal.
+type GetStethoSignalParam struct {
+	SignalID int64 `json:"signalid"`
+}
+
+// UpdateQuery updates the query provided with the parameters of this param.
+func (p *GetStethoSignalParam) UpdateQuery(q url.Values) url.Values {
+	// Constructing the query parameters based on the param provided.
+	q.Set("action", APIActionGetStethoSignal)
+	q.Set("signalid", strconv.FormatInt(p.SignalID, 10))
+
+	return q
+}
+
+// GetStethoSignal retrieves the audio signal of a stethoscope recording for the user represented by the token. Error
+// will be non nil upon an internal or api error. If the API returned the error the response will contain the error.
+func (c *Client) GetStethoSignal(ctx context.Context, token AccessToken, param GetStethoSignalParam) (*GetStethoSignalResp, error) {
+
+	// Construct authorized request to request data from the API.
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.apiURL(PathStethoV2), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build http request: %w", err)
+	}
+	req = authorize(req, token)
+
+	// Updating the query with the parameters generated by the param provided.
+	req.URL.RawQuery = param.UpdateQuery(req.URL.Query()).Encode()
+
+	// Executing the request.
+	var mResp GetStethoSignalResp
+	if err = c.do(req, &mResp); err != nil {
+		return nil, err
+	}
+
+	switch mResp.Status {
+	case 0:
+		return &mResp, nil
+	default:
+		return &mResp, newAPIError(req, mResp.Status, mResp.APIError)
+	}
+}
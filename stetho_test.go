@@ -0,0 +1,106 @@
+package withings_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jrmycanady/withings"
+	"github.com/jrmycanady/withings/withingstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_GetStetho(t *testing.T) {
+	t.Parallel()
+
+	srv := withingstest.NewServer()
+	defer srv.Close()
+	srv.AddUser(withingstest.NewDemoUser("1", time.Now()))
+	c := srv.NewClient(url.URL{})
+	ctx := context.Background()
+	token := srv.IssueToken("1")
+
+	list, err := c.GetStethoList(ctx, token, withings.GetStethoListParam{})
+	require.Nil(t, err)
+	require.NotEmpty(t, list.Body.Series)
+
+	recording := list.Body.Series[0]
+	assert.NotZero(t, recording.SignalID)
+	assert.NotEmpty(t, recording.DeviceID)
+
+	signal, err := c.GetStethoSignal(ctx, token, withings.GetStethoSignalParam{SignalID: recording.SignalID})
+	require.Nil(t, err)
+	assert.Equal(t, []int64{0, 120, -120, 60, -60, 0}, signal.Body.Signal)
+	assert.Equal(t, int64(4000), signal.Body.Frequency)
+
+	_, err = c.GetStethoSignal(ctx, token, withings.GetStethoSignalParam{SignalID: -1})
+	assert.ErrorIs(t, err, withings.ErrInvalidParams)
+}
+
+func TestStethoSignal_WriteWAV(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		signal  withings.StethoSignal
+		samples []int16
+		err     error
+	}{
+		{
+			name:    "Samples",
+			signal:  withings.StethoSignal{Signal: []int64{0, 1, -1, 1000}, Frequency: 4000},
+			samples: []int16{0, 1, -1, 1000},
+		},
+		{
+			name:    "Clips samples",
+			signal:  withings.StethoSignal{Signal: []int64{40000, -40000}, Frequency: 4000},
+			samples: []int16{32767, -32768},
+		},
+		{
+			name:    "Empty signal",
+			signal:  withings.StethoSignal{Frequency: 2000},
+			samples: []int16{},
+		},
+		{
+			name:   "No frequency",
+			signal: withings.StethoSignal{Signal: []int64{1}},
+			err:    withings.ErrNoSamplingFrequency,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			err := tt.signal.WriteWAV(&buf)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.Nil(t, err)
+
+			data := buf.Bytes()
+			require.Len(t, data, 44+2*len(tt.samples))
+			assert.Equal(t, "RIFF", string(data[0:4]))
+			assert.Equal(t, uint32(36+2*len(tt.samples)), binary.LittleEndian.Uint32(data[4:8]))
+			assert.Equal(t, "WAVEfmt ", string(data[8:16]))
+			assert.Equal(t, uint16(1), binary.LittleEndian.Uint16(data[20:22]))
+			assert.Equal(t, uint16(1), binary.LittleEndian.Uint16(data[22:24]))
+			assert.Equal(t, uint32(tt.signal.Frequency), binary.LittleEndian.Uint32(data[24:28]))
+			assert.Equal(t, uint32(2*tt.signal.Frequency), binary.LittleEndian.Uint32(data[28:32]))
+			assert.Equal(t, uint16(16), binary.LittleEndian.Uint16(data[34:36]))
+			assert.Equal(t, "data", string(data[36:40]))
+			assert.Equal(t, uint32(2*len(tt.samples)), binary.LittleEndian.Uint32(data[40:44]))
+
+			samples := make([]int16, len(tt.samples))
+			require.Nil(t, binary.Read(bytes.NewReader(data[44:]), binary.LittleEndian, samples))
+			assert.Equal(t, tt.samples, samples)
+		})
+	}
+}
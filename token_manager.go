@@ -0,0 +1,116 @@
+package withings
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// tokenRefreshMargin is how long before it expires a token is refreshed.
+const tokenRefreshMargin = 10 * time.Second
+
+// tokenManager keeps the latest token of every user of a client keyed by Withings user ID. Every AuthorizedUser of the
+// client obtains its token through the manager, so concurrent refreshes of the same user are coalesced into a single
+// refresh whose result is shared, even when the AuthorizedUser values were built from separate copies of the token.
+// This matters as refresh tokens are single use: a second refresh with the same refresh token fails and the token it
+// rotated to would be lost.
+type tokenManager struct {
+	c *Client
+
+	mu    sync.Mutex
+	users map[string]*sharedToken
+}
+
+// sharedToken is the latest token of a user. The lock is held while the token is refreshed so concurrent refreshes of
+// the user wait for and share the result of the first.
+type sharedToken struct {
+	sync.Mutex
+	t AccessToken
+}
+
+func newTokenManager(c *Client) *tokenManager {
+	return &tokenManager{
+		c:     c,
+		users: make(map[string]*sharedToken),
+	}
+}
+
+// token returns a token of the user that is valid beyond validUntil. If neither t nor the latest token known for the
+// user is, the latest token is refreshed, unless another refresh of the user is in progress in which case its result
+// is waited for. The token response is only returned to the caller that performed the refresh.
+//
+// If the refresh is rejected because the refresh token was already used, by another process for example, the token
+// stored for the user in store is loaded instead. store may be nil. Tokens without a user ID are refreshed without
+// coordination.
+func (m *tokenManager) token(ctx context.Context, t AccessToken, store TokenStore, validUntil time.Time) (AccessToken, *AccessTokenResponse, error) {
+	if t.UserID == "" {
+		if t.ExpiresAt.After(validUntil) {
+			return t, nil, nil
+		}
+		tokenResp, err := m.c.RefreshAccessTokenContext(ctx, t)
+		if err != nil {
+			return t, tokenResp, err
+		}
+		return tokenResp.AccessToken, tokenResp, nil
+	}
+
+	s := m.shared(t)
+	s.Lock()
+	defer s.Unlock()
+
+	if t.ExpiresAt.After(s.t.ExpiresAt) {
+		s.t = t
+	}
+	if s.t.ExpiresAt.After(validUntil) {
+		return s.t, nil, nil
+	}
+
+	tokenResp, err := m.c.RefreshAccessTokenContext(ctx, s.t)
+	if err != nil && store != nil && refreshTokenRejected(err) {
+		stored, loadErr := store.Load(ctx, s.t.UserID)
+		if loadErr != nil || stored.RefreshToken == s.t.RefreshToken {
+			return s.t, tokenResp, err
+		}
+
+		// The token was refreshed elsewhere. The stored token is used as is unless it is not valid long enough either.
+		s.t = *stored
+		if s.t.ExpiresAt.After(validUntil) {
+			return s.t, nil, nil
+		}
+		tokenResp, err = m.c.RefreshAccessTokenContext(ctx, s.t)
+	}
+	if err != nil {
+		return s.t, tokenResp, err
+	}
+
+	s.t = tokenResp.AccessToken
+	return s.t, tokenResp, nil
+}
+
+// shared returns the latest token of the user of t, registering t if the user is not known yet.
+func (m *tokenManager) shared(t AccessToken) *sharedToken {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.users[t.UserID]
+	if !ok {
+		s = &sharedToken{t: t}
+		m.users[t.UserID] = s
+	}
+	return s
+}
+
+// forget discards the token known for the user.
+func (m *tokenManager) forget(userID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.users, userID)
+}
+
+// refreshTokenRejected reports whether the refresh failed because the API rejected the refresh token, which happens
+// when it was already used.
+func refreshTokenRejected(err error) bool {
+	return errors.Is(err, ErrInvalidParams) || errors.Is(err, ErrInvalidToken)
+}
@@ -0,0 +1,105 @@
+package withings_test
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jrmycanady/withings"
+	"github.com/jrmycanady/withings/withingstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// expiredToken issues a token for the user that the client considers expired.
+func expiredToken(srv *withingstest.Server, userID string) withings.AccessToken {
+	token := srv.IssueToken(userID)
+	token.ExpiresAt = time.Now().Add(-time.Minute)
+	return token
+}
+
+func TestAuthorizedUser_SharedRefresh(t *testing.T) {
+	t.Parallel()
+
+	srv := withingstest.NewServer()
+	defer srv.Close()
+	srv.AddUser(withingstest.NewDemoUser("1", time.Now()))
+	c := srv.NewClient(url.URL{})
+	ctx := context.Background()
+
+	token := expiredToken(srv, "1")
+	var refreshes [2]int
+	users := make([]*withings.AuthorizedUser, 2)
+	for i := range users {
+		i := i
+		users[i] = c.NewAuthorizedUser(token, withings.OnTokenRefresh(func(old withings.AccessToken, new withings.AccessToken) {
+			refreshes[i]++
+		}))
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		for _, u := range users {
+			wg.Add(1)
+			go func(api *withings.UserAPI) {
+				defer wg.Done()
+
+				_, err := api.GetMeasure(ctx, withings.GetMeasureParam{})
+				assert.Nil(t, err)
+			}(u.API())
+		}
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, srv.RequestCount(withings.PathOAuth2, "requesttoken"))
+	assert.Equal(t, users[0].Token(), users[1].Token())
+	assert.NotEqual(t, token.AccessToken, users[0].Token().AccessToken)
+	assert.Equal(t, [2]int{1, 1}, refreshes)
+}
+
+func TestAuthorizedUser_RefreshTokenAlreadyUsed(t *testing.T) {
+	t.Parallel()
+
+	srv := withingstest.NewServer()
+	defer srv.Close()
+	srv.AddUser(withingstest.NewDemoUser("1", time.Now()))
+	ctx := context.Background()
+
+	t.Run("Reloads from the store", func(t *testing.T) {
+		store := withings.NewMemoryTokenStore()
+		require.Nil(t, store.Save(ctx, "1", expiredToken(srv, "1")))
+
+		// Separate clients stand in for separate processes sharing the store.
+		first, err := srv.NewClient(url.URL{}).LoadAuthorizedUser(ctx, store, "1")
+		require.Nil(t, err)
+		second, err := srv.NewClient(url.URL{}).LoadAuthorizedUser(ctx, store, "1")
+		require.Nil(t, err)
+
+		_, token, err := first.GetMeasure(ctx, withings.GetMeasureParam{})
+		require.Nil(t, err)
+		require.NotNil(t, token)
+
+		_, reloaded, err := second.GetMeasure(ctx, withings.GetMeasureParam{})
+		require.Nil(t, err)
+		require.NotNil(t, reloaded)
+		assert.Equal(t, *token, *reloaded)
+
+		stored, err := store.Load(ctx, "1")
+		require.Nil(t, err)
+		assert.Equal(t, *token, *stored)
+	})
+
+	t.Run("Fails without a store", func(t *testing.T) {
+		token := expiredToken(srv, "1")
+		first := srv.NewClient(url.URL{}).NewAuthorizedUser(token)
+		second := srv.NewClient(url.URL{}).NewAuthorizedUser(token)
+
+		_, _, err := first.GetMeasure(ctx, withings.GetMeasureParam{})
+		require.Nil(t, err)
+
+		_, _, err = second.GetMeasure(ctx, withings.GetMeasureParam{})
+		assert.ErrorIs(t, err, withings.ErrInvalidParams)
+	})
+}
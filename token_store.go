@@ -0,0 +1,373 @@
+package withings
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// ErrTokenNotFound is returned by a TokenStore when no token is stored for a user.
+var ErrTokenNotFound = errors.New("token not found")
+
+// TokenStore persists the access tokens of users keyed by their Withings user ID. Implementations must be safe for
+// concurrent use.
+type TokenStore interface {
+	// Load returns the token stored for the user. If no token is stored ErrTokenNotFound is returned.
+	Load(ctx context.Context, userID string) (*AccessToken, error)
+
+	// Save stores the token for the user, replacing any token already stored.
+	Save(ctx context.Context, userID string, token AccessToken) error
+
+	// Delete removes the token stored for the user. Deleting a token that is not stored is not an error.
+	Delete(ctx context.Context, userID string) error
+}
+
+// TokenLister is implemented by token stores able to list the users they store a token for.
+type TokenLister interface {
+	// List returns the Withings user IDs of every user a token is stored for, sorted.
+	List(ctx context.Context) ([]string, error)
+}
+
+// sortedUserIDs returns the keys of tokens sorted.
+func sortedUserIDs(tokens map[string]AccessToken) []string {
+	userIDs := make([]string, 0, len(tokens))
+	for userID := range tokens {
+		userIDs = append(userIDs, userID)
+	}
+	sort.Strings(userIDs)
+	return userIDs
+}
+
+// MemoryTokenStore is a TokenStore that keeps tokens in memory. Tokens are lost when the process exits.
+type MemoryTokenStore struct {
+	mu     sync.RWMutex
+	tokens map[string]AccessToken
+}
+
+// NewMemoryTokenStore returns an empty MemoryTokenStore.
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{
+		tokens: make(map[string]AccessToken),
+	}
+}
+
+// Load returns the token stored for the user.
+func (s *MemoryTokenStore) Load(_ context.Context, userID string) (*AccessToken, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, ok := s.tokens[userID]
+	if !ok {
+		return nil, ErrTokenNotFound
+	}
+	return &t, nil
+}
+
+// Save stores the token for the user.
+func (s *MemoryTokenStore) Save(_ context.Context, userID string, token AccessToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokens[userID] = token
+	return nil
+}
+
+// List returns the user IDs of every user a token is stored for.
+func (s *MemoryTokenStore) List(_ context.Context) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return sortedUserIDs(s.tokens), nil
+}
+
+// Delete removes the token stored for the user.
+func (s *MemoryTokenStore) Delete(_ context.Context, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.tokens, userID)
+	return nil
+}
+
+// FileTokenStore is a TokenStore that keeps the tokens of every user in a single JSON file, optionally encrypted. The
+// file is replaced atomically on every change so a crash never leaves a partially written file behind. A
+// FileTokenStore is only safe for concurrent use within a single process.
+type FileTokenStore struct {
+	mu   sync.Mutex
+	path string
+
+	// The passphrase the file is encrypted with. If empty the file is stored as plain JSON.
+	passphrase []byte
+
+	// The cipher keyed from the passphrase and salt, cached as deriving the key is deliberately slow. It is reused
+	// for every read and write of a file with the same salt.
+	salt []byte
+	gcm  cipher.AEAD
+}
+
+// NewFileTokenStore returns a store that keeps the tokens as plain JSON in the file at path. The file is created with
+// permissions restricted to the current user on the first save.
+func NewFileTokenStore(path string) *FileTokenStore {
+	return &FileTokenStore{
+		path: path,
+	}
+}
+
+// NewEncryptedFileTokenStore returns a store that keeps the tokens in the file at path encrypted with AES-256-GCM. The
+// key is derived from the passphrase with scrypt once per salt and kept by the store.
+func NewEncryptedFileTokenStore(path string, passphrase string) (*FileTokenStore, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("passphrase must not be empty")
+	}
+
+	return &FileTokenStore{
+		path:       path,
+		passphrase: []byte(passphrase),
+	}, nil
+}
+
+// NewEncryptedFileTokenStoreFromEnv returns a store like NewEncryptedFileTokenStore with the passphrase read from the
+// environment variable provided.
+func NewEncryptedFileTokenStoreFromEnv(path string, envVar string) (*FileTokenStore, error) {
+	passphrase := os.Getenv(envVar)
+	if passphrase == "" {
+		return nil, fmt.Errorf("environment variable %s is not set", envVar)
+	}
+
+	return NewEncryptedFileTokenStore(path, passphrase)
+}
+
+// Load returns the token stored for the user.
+func (s *FileTokenStore) Load(_ context.Context, userID string) (*AccessToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+
+	t, ok := tokens[userID]
+	if !ok {
+		return nil, ErrTokenNotFound
+	}
+	return &t, nil
+}
+
+// Save stores the token for the user.
+func (s *FileTokenStore) Save(_ context.Context, userID string, token AccessToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	tokens[userID] = token
+	return s.write(tokens)
+}
+
+// List returns the user IDs of every user a token is stored for.
+func (s *FileTokenStore) List(_ context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	return sortedUserIDs(tokens), nil
+}
+
+// Delete removes the token stored for the user.
+func (s *FileTokenStore) Delete(_ context.Context, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens, err := s.read()
+	if err != nil {
+		return err
+	}
+	if _, ok := tokens[userID]; !ok {
+		return nil
+	}
+
+	delete(tokens, userID)
+	return s.write(tokens)
+}
+
+// read returns the tokens in the file. A missing file holds no tokens. The caller must hold s.mu.
+func (s *FileTokenStore) read() (map[string]AccessToken, error) {
+	tokens := make(map[string]AccessToken)
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return tokens, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token file: %w", err)
+	}
+
+	if len(s.passphrase) > 0 {
+		if data, err = s.decrypt(data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = json.Unmarshal(data, &tokens); err != nil {
+		return nil, fmt.Errorf("failed to parse token file: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// write replaces the file with the tokens provided by writing a temporary file in the same directory and renaming it
+// over the file. The caller must hold s.mu.
+func (s *FileTokenStore) write(tokens map[string]AccessToken) error {
+	data, err := json.Marshal(tokens)
+	if err != nil {
+		return fmt.Errorf("failed to marshal tokens: %w", err)
+	}
+
+	if len(s.passphrase) > 0 {
+		if data, err = s.encrypt(data); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary token file: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write temporary token file: %w", err)
+	}
+	if err = f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to sync temporary token file: %w", err)
+	}
+	if err = f.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary token file: %w", err)
+	}
+
+	if err = os.Rename(f.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to replace token file: %w", err)
+	}
+
+	return nil
+}
+
+const (
+	// The parameters used to derive the key of encrypted token files from the passphrase.
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+	saltLen      = 16
+)
+
+// encryptedTokensHeader identifies and versions the format of encrypted token files.
+var encryptedTokensHeader = []byte("WTS1")
+
+// encrypt encrypts data with the cached cipher, or a cipher keyed from the passphrase and a random salt if none is
+// cached yet. The result contains the header, salt, nonce and sealed data. The caller must hold s.mu.
+func (s *FileTokenStore) encrypt(data []byte) ([]byte, error) {
+	if s.gcm == nil {
+		salt := make([]byte, saltLen)
+		if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+			return nil, fmt.Errorf("failed to generate salt: %w", err)
+		}
+		if _, err := s.cipher(salt); err != nil {
+			return nil, err
+		}
+	}
+
+	nonce := make([]byte, s.gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	out := make([]byte, 0, len(encryptedTokensHeader)+len(s.salt)+len(nonce)+len(data)+s.gcm.Overhead())
+	out = append(out, encryptedTokensHeader...)
+	out = append(out, s.salt...)
+	out = append(out, nonce...)
+	return s.gcm.Seal(out, nonce, data, encryptedTokensHeader), nil
+}
+
+// decrypt reverses encrypt. The caller must hold s.mu.
+func (s *FileTokenStore) decrypt(data []byte) ([]byte, error) {
+	if len(data) < len(encryptedTokensHeader)+saltLen || string(data[:len(encryptedTokensHeader)]) != string(encryptedTokensHeader) {
+		return nil, fmt.Errorf("token file is not encrypted")
+	}
+	data = data[len(encryptedTokensHeader):]
+
+	gcm, err := s.cipher(data[:saltLen])
+	if err != nil {
+		return nil, err
+	}
+	data = data[saltLen:]
+
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("token file is truncated")
+	}
+
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], encryptedTokensHeader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt token file, the passphrase may be wrong: %w", err)
+	}
+
+	return plain, nil
+}
+
+// cipher returns the cipher keyed from the passphrase and salt. The key is only derived if the salt differs from the
+// salt of the cached cipher, such as when the file was written by another store. The caller must hold s.mu.
+func (s *FileTokenStore) cipher(salt []byte) (cipher.AEAD, error) {
+	if s.gcm != nil && bytes.Equal(s.salt, salt) {
+		return s.gcm, nil
+	}
+
+	gcm, err := newTokenCipher(s.passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	s.salt = append([]byte(nil), salt...)
+	s.gcm = gcm
+	return gcm, nil
+}
+
+// newTokenCipher returns the AES-GCM cipher keyed from the passphrase and salt.
+func newTokenCipher(passphrase []byte, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key(passphrase, salt, scryptN, scryptR, scryptP, scryptKeyLen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	return gcm, nil
+}
@@ -0,0 +1,206 @@
+package withings_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jrmycanady/withings"
+	"github.com/jrmycanady/withings/withingstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenStore(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	encrypted, err := withings.NewEncryptedFileTokenStore(filepath.Join(dir, "encrypted.json"), "passphrase")
+	require.Nil(t, err)
+
+	tests := []struct {
+		name  string
+		store withings.TokenStore
+	}{
+		{name: "Memory", store: withings.NewMemoryTokenStore()},
+		{name: "File", store: withings.NewFileTokenStore(filepath.Join(dir, "tokens.json"))},
+		{name: "Encrypted file", store: encrypted},
+	}
+
+	for _, test := range tests {
+		ctx := context.Background()
+		token := withings.AccessToken{
+			AccessToken:  "access",
+			RefreshToken: "refresh",
+			ExpiresIn:    10800,
+			ExpiresAt:    time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC),
+			TokenType:    "Bearer",
+		}
+
+		_, err := test.store.Load(ctx, "1")
+		assert.True(t, errors.Is(err, withings.ErrTokenNotFound), test.name)
+
+		require.Nil(t, test.store.Save(ctx, "1", token), test.name)
+		require.Nil(t, test.store.Save(ctx, "2", withings.AccessToken{AccessToken: "other"}), test.name)
+
+		loaded, err := test.store.Load(ctx, "1")
+		require.Nil(t, err, test.name)
+		assert.Equal(t, token, *loaded, test.name)
+
+		userIDs, err := test.store.(withings.TokenLister).List(ctx)
+		require.Nil(t, err, test.name)
+		assert.Equal(t, []string{"1", "2"}, userIDs, test.name)
+
+		token.RefreshToken = "rotated"
+		require.Nil(t, test.store.Save(ctx, "1", token), test.name)
+		loaded, err = test.store.Load(ctx, "1")
+		require.Nil(t, err, test.name)
+		assert.Equal(t, "rotated", loaded.RefreshToken, test.name)
+
+		require.Nil(t, test.store.Delete(ctx, "1"), test.name)
+		require.Nil(t, test.store.Delete(ctx, "1"), test.name)
+		_, err = test.store.Load(ctx, "1")
+		assert.True(t, errors.Is(err, withings.ErrTokenNotFound), test.name)
+
+		loaded, err = test.store.Load(ctx, "2")
+		require.Nil(t, err, test.name)
+		assert.Equal(t, "other", loaded.AccessToken, test.name)
+	}
+
+	// Only the token files remain, the temporary files have been renamed over them.
+	entries, err := os.ReadDir(dir)
+	require.Nil(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func TestFileTokenStore_Persists(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	ctx := context.Background()
+
+	require.Nil(t, withings.NewFileTokenStore(path).Save(ctx, "1", withings.AccessToken{RefreshToken: "refresh"}))
+
+	info, err := os.Stat(path)
+	require.Nil(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	loaded, err := withings.NewFileTokenStore(path).Load(ctx, "1")
+	require.Nil(t, err)
+	assert.Equal(t, "refresh", loaded.RefreshToken)
+}
+
+func TestEncryptedFileTokenStore(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "tokens.enc")
+	ctx := context.Background()
+
+	store, err := withings.NewEncryptedFileTokenStore(path, "correct horse")
+	require.Nil(t, err)
+	require.Nil(t, store.Save(ctx, "1", withings.AccessToken{RefreshToken: "secret-refresh-token"}))
+
+	data, err := os.ReadFile(path)
+	require.Nil(t, err)
+	assert.NotContains(t, string(data), "secret-refresh-token")
+
+	wrong, err := withings.NewEncryptedFileTokenStore(path, "battery staple")
+	require.Nil(t, err)
+	_, err = wrong.Load(ctx, "1")
+	assert.NotNil(t, err)
+
+	_, err = withings.NewFileTokenStore(path).Load(ctx, "1")
+	assert.NotNil(t, err, "encrypted files are not plain JSON")
+
+	_, err = withings.NewEncryptedFileTokenStore(path, "")
+	assert.NotNil(t, err)
+}
+
+func TestEncryptedFileTokenStore_Salt(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tokens.enc")
+	ctx := context.Background()
+
+	store, err := withings.NewEncryptedFileTokenStore(path, "correct horse")
+	require.Nil(t, err)
+
+	// The salt and the key derived from it are reused by every write of the store.
+	require.Nil(t, store.Save(ctx, "1", withings.AccessToken{AccessToken: "1"}))
+	first, err := os.ReadFile(path)
+	require.Nil(t, err)
+	require.Nil(t, store.Save(ctx, "2", withings.AccessToken{AccessToken: "2"}))
+	second, err := os.ReadFile(path)
+	require.Nil(t, err)
+	assert.Equal(t, first[4:20], second[4:20])
+	assert.NotEqual(t, first, second)
+
+	// A file written by another store with a different salt is still read.
+	other, err := withings.NewEncryptedFileTokenStore(filepath.Join(dir, "other.enc"), "correct horse")
+	require.Nil(t, err)
+	require.Nil(t, other.Save(ctx, "3", withings.AccessToken{AccessToken: "3"}))
+	data, err := os.ReadFile(filepath.Join(dir, "other.enc"))
+	require.Nil(t, err)
+	assert.NotEqual(t, first[4:20], data[4:20])
+	require.Nil(t, os.WriteFile(path, data, 0600))
+
+	token, err := store.Load(ctx, "3")
+	require.Nil(t, err)
+	assert.Equal(t, "3", token.AccessToken)
+	_, err = store.Load(ctx, "1")
+	assert.True(t, errors.Is(err, withings.ErrTokenNotFound))
+}
+
+func TestNewEncryptedFileTokenStoreFromEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.enc")
+	ctx := context.Background()
+	t.Setenv("WITHINGS_TEST_TOKEN_PASSPHRASE", "correct horse")
+
+	store, err := withings.NewEncryptedFileTokenStoreFromEnv(path, "WITHINGS_TEST_TOKEN_PASSPHRASE")
+	require.Nil(t, err)
+	require.Nil(t, store.Save(ctx, "1", withings.AccessToken{RefreshToken: "refresh"}))
+
+	store, err = withings.NewEncryptedFileTokenStore(path, "correct horse")
+	require.Nil(t, err)
+	loaded, err := store.Load(ctx, "1")
+	require.Nil(t, err)
+	assert.Equal(t, "refresh", loaded.RefreshToken)
+
+	_, err = withings.NewEncryptedFileTokenStoreFromEnv(path, "WITHINGS_TEST_TOKEN_PASSPHRASE_UNSET")
+	assert.NotNil(t, err)
+}
+
+func TestAuthorizedUser_TokenStore(t *testing.T) {
+	t.Parallel()
+
+	srv := withingstest.NewServer()
+	defer srv.Close()
+	srv.AddUser(withingstest.NewDemoUser("1", time.Now()))
+	c := srv.NewClient(url.URL{})
+	ctx := context.Background()
+
+	store := withings.NewMemoryTokenStore()
+	token := srv.IssueToken("1")
+	token.ExpiresAt = time.Now().Add(-time.Minute)
+	require.Nil(t, store.Save(ctx, "1", token))
+
+	u, err := c.LoadAuthorizedUser(ctx, store, "1")
+	require.Nil(t, err)
+
+	// The refreshed token is saved even though the caller ignores it.
+	_, _, err = u.GetMeasure(ctx, withings.GetMeasureParam{})
+	require.Nil(t, err)
+
+	saved, err := store.Load(ctx, "1")
+	require.Nil(t, err)
+	assert.NotEqual(t, token.RefreshToken, saved.RefreshToken)
+	assert.True(t, saved.ExpiresAt.After(time.Now()))
+
+	_, err = c.LoadAuthorizedUser(ctx, store, "2")
+	assert.True(t, errors.Is(err, withings.ErrTokenNotFound))
+}
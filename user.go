@@ -2,10 +2,9 @@ package withings
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
+	"time"
 )
 
 // Device is device as defined by the Withings API.
@@ -42,35 +41,95 @@ type GetUserDeviceBody struct {
 func (c *Client) GetUserDevice(ctx context.Context, token AccessToken) (*GetUserDeviceResp, error) {
 
 	// Construct authorized request to request data from the API.
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, APIUser, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.apiURL(PathUserV2), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build http request: %w", err)
 	}
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token.AccessToken))
+	req = authorize(req, token)
 	q := req.URL.Query()
 	q.Set("action", APIActionUserGetDevice)
 	req.URL.RawQuery = q.Encode()
 
 	// Executing the request.
-	resp, err := c.HttpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
+	var mResp GetUserDeviceResp
+	if err = c.do(req, &mResp); err != nil {
+		return nil, err
+	}
+
+	switch mResp.Status {
+	case 0:
+		return &mResp, nil
+	default:
+		return &mResp, newAPIError(req, mResp.Status, mResp.APIError)
 	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
+}
+
+// Goals are the goals a user configured in the Withings app as defined by the Withings API.
+type Goals struct {
+	// The daily number of steps.
+	Steps int64 `json:"steps"`
+
+	// The daily sleep duration in seconds.
+	Sleep int64 `json:"sleep"`
+
+	// The weight target. It is nil if the user has not set one.
+	Weight *WeightGoal `json:"weight"`
+}
+
+// SleepDuration returns the daily sleep duration goal.
+func (g *Goals) SleepDuration() time.Duration {
+	return time.Duration(g.Sleep) * time.Second
+}
+
+// WeightGoal is a weight target. The value is encoded like the value of a Measure.
+type WeightGoal struct {
+	Value int64 `json:"value"`
+	Unit  int   `json:"unit"`
+}
+
+// DecimalValue returns the weight target in kilograms by applying the unit value representing the decimal location,
+// as Measure.DecimalValue does.
+func (w *WeightGoal) DecimalValue() float64 {
+	m := Measure{Value: w.Value, Type: MeasureTypeWeightKilogram, Unit: w.Unit}
+	return m.DecimalValue()
+}
+
+// GetUserGoalsResp is the response type returned by the Withings API for a request for user goals.
+type GetUserGoalsResp struct {
+	Status   int64            `json:"status"`
+	APIError string           `json:"error"`
+	Body     GetUserGoalsBody `json:"body"`
+}
+
+// GetUserGoalsBody is the body of the response returned by the Withings API for a request for user goals.
+type GetUserGoalsBody struct {
+	Goals Goals `json:"goals"`
+}
+
+// GetUserGoals retrieves the goals of the user represented by the token. Error will be non nil upon an internal
+// or api error. If the API returned the error the response will contain the error.
+func (c *Client) GetUserGoals(ctx context.Context, token AccessToken) (*GetUserGoalsResp, error) {
+
+	// Construct authorized request to request data from the API.
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.apiURL(PathUserV2), nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read body of request: %w", err)
+		return nil, fmt.Errorf("failed to build http request: %w", err)
 	}
+	req = authorize(req, token)
+	q := req.URL.Query()
+	q.Set("action", APIActionUserGetGoals)
+	req.URL.RawQuery = q.Encode()
 
-	var mResp GetUserDeviceResp
-	if err = json.Unmarshal(body, &mResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	// Executing the request.
+	var mResp GetUserGoalsResp
+	if err = c.do(req, &mResp); err != nil {
+		return nil, err
 	}
 
 	switch mResp.Status {
 	case 0:
 		return &mResp, nil
 	default:
-		return &mResp, fmt.Errorf("api returned an error: %s", mResp.APIError)
+		return &mResp, newAPIError(req, mResp.Status, mResp.APIError)
 	}
 }
@@ -0,0 +1,129 @@
+package withings
+
+import "context"
+
+// UserAPI provides the data methods of an AuthorizedUser without the refreshed token return value. Refreshed tokens
+// are handled centrally with the OnTokenRefresh and WithTokenStore options instead.
+type UserAPI struct {
+	a *AuthorizedUser
+}
+
+// API returns the simplified method set of the AuthorizedUser.
+func (a *AuthorizedUser) API() *UserAPI {
+	return &UserAPI{a: a}
+}
+
+// GetMeasure returns the measures for the user based on the param provided.
+func (u *UserAPI) GetMeasure(ctx context.Context, param GetMeasureParam) (*GetMeasureResp, error) {
+	resp, _, err := u.a.GetMeasure(ctx, param)
+	return resp, err
+}
+
+// GetIntraDayActivity returns the intra day activities for the user based on the param provided.
+func (u *UserAPI) GetIntraDayActivity(ctx context.Context, param GetIntraDayActivityParam) (*GetIntraDayActivityResp, error) {
+	resp, _, err := u.a.GetIntraDayActivity(ctx, param)
+	return resp, err
+}
+
+// GetIntraDayActivityChunked returns the intra day activities for the user over a range longer than the API allows in
+// one request.
+func (u *UserAPI) GetIntraDayActivityChunked(ctx context.Context, param GetIntraDayActivityParam, opts ChunkOptions) (*GetIntraDayActivityResp, error) {
+	resp, _, err := u.a.GetIntraDayActivityChunked(ctx, param, opts)
+	return resp, err
+}
+
+// GetActivity returns the activities for the user based on the param provided.
+func (u *UserAPI) GetActivity(ctx context.Context, param GetActivityParam) (*GetActivityResp, error) {
+	resp, _, err := u.a.GetActivity(ctx, param)
+	return resp, err
+}
+
+// GetHeartList returns the heart data for the user based on the param provided.
+func (u *UserAPI) GetHeartList(ctx context.Context, param GetHeartListParam) (*GetHeartResp, error) {
+	resp, _, err := u.a.GetHeartList(ctx, param)
+	return resp, err
+}
+
+// GetHeartHighFrequencyData returns the high frequency heart data for the user based on the param provided.
+func (u *UserAPI) GetHeartHighFrequencyData(ctx context.Context, param GetHeartHighFrequencyDataParam) (*GetHeartHighFrequencyDataResp, error) {
+	resp, _, err := u.a.GetHeartHighFrequencyData(ctx, param)
+	return resp, err
+}
+
+// GetStethoList returns the stethoscope recordings for the user based on the param provided.
+func (u *UserAPI) GetStethoList(ctx context.Context, param GetStethoListParam) (*GetStethoListResp, error) {
+	resp, _, err := u.a.GetStethoList(ctx, param)
+	return resp, err
+}
+
+// GetStethoSignal returns the audio signal of a stethoscope recording for the user based on the param provided.
+func (u *UserAPI) GetStethoSignal(ctx context.Context, param GetStethoSignalParam) (*GetStethoSignalResp, error) {
+	resp, _, err := u.a.GetStethoSignal(ctx, param)
+	return resp, err
+}
+
+// GetSleep returns the sleep data for the user based on the param provided.
+func (u *UserAPI) GetSleep(ctx context.Context, param GetSleepParam) (*GetSleepResp, error) {
+	resp, _, err := u.a.GetSleep(ctx, param)
+	return resp, err
+}
+
+// GetSleepChunked returns the sleep data for the user over a range longer than the API allows in one request.
+func (u *UserAPI) GetSleepChunked(ctx context.Context, param GetSleepParam, opts ChunkOptions) (*GetSleepResp, error) {
+	resp, _, err := u.a.GetSleepChunked(ctx, param, opts)
+	return resp, err
+}
+
+// GetSleepSummary returns the sleep summaries for the user based on the param provided.
+func (u *UserAPI) GetSleepSummary(ctx context.Context, param GetSleepSummaryParam) (*GetSleepSummaryResp, error) {
+	resp, _, err := u.a.GetSleepSummary(ctx, param)
+	return resp, err
+}
+
+// GetWorkout returns the workouts for the user based on the param provided.
+func (u *UserAPI) GetWorkout(ctx context.Context, param GetWorkoutParam) (*GetWorkoutResp, error) {
+	resp, _, err := u.a.GetWorkout(ctx, param)
+	return resp, err
+}
+
+// GetUserDevice returns the devices linked to the user.
+func (u *UserAPI) GetUserDevice(ctx context.Context) (*GetUserDeviceResp, error) {
+	resp, _, err := u.a.GetUserDevice(ctx)
+	return resp, err
+}
+
+// GetUserGoals returns the goals of the user.
+func (u *UserAPI) GetUserGoals(ctx context.Context) (*GetUserGoalsResp, error) {
+	resp, _, err := u.a.GetUserGoals(ctx)
+	return resp, err
+}
+
+// SubscribeToNotifications subscribes the user to the notifications described by the param provided.
+func (u *UserAPI) SubscribeToNotifications(ctx context.Context, param SubscribeToNotificationsParam) (*SubscribeToNotificationsResp, error) {
+	resp, _, err := u.a.SubscribeToNotifications(ctx, param)
+	return resp, err
+}
+
+// GetNotification returns the notification subscription of the user matching the param provided.
+func (u *UserAPI) GetNotification(ctx context.Context, param GetNotificationParam) (*GetNotificationResp, error) {
+	resp, _, err := u.a.GetNotification(ctx, param)
+	return resp, err
+}
+
+// ListNotification lists the notification subscriptions of the user based on the param provided.
+func (u *UserAPI) ListNotification(ctx context.Context, param ListNotificationParam) (*ListNotificationResp, error) {
+	resp, _, err := u.a.ListNotification(ctx, param)
+	return resp, err
+}
+
+// RevokeNotification revokes the notification subscription of the user matching the param provided.
+func (u *UserAPI) RevokeNotification(ctx context.Context, param RevokeNotificationParam) (*RevokeNotificationResp, error) {
+	resp, _, err := u.a.RevokeNotification(ctx, param)
+	return resp, err
+}
+
+// UpdateNotification updates the notification subscription of the user based on the param provided.
+func (u *UserAPI) UpdateNotification(ctx context.Context, param UpdateNotificationParam) (*UpdateNotificationResp, error) {
+	resp, _, err := u.a.UpdateNotification(ctx, param)
+	return resp, err
+}
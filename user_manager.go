@@ -0,0 +1,275 @@
+package withings
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// UserManagerOptions configures a UserManager. The zero value uses the defaults.
+type UserManagerOptions struct {
+	// The maximum number of calls to Do running at once across every user. If zero the number is not limited.
+	MaxConcurrency int
+
+	// The maximum number of calls to Do running at once for a single user. If zero calls for the same user run one
+	// at a time.
+	MaxConcurrencyPerUser int
+
+	// If set every managed user is registered with the refresher and unregistered once removed.
+	Refresher *Refresher
+
+	// Options applied to the AuthorizedUser of every managed user. The token store of the manager is always applied.
+	UserOptions []AuthorizedUserOption
+}
+
+// UserState is the state of a user managed by a UserManager.
+type UserState struct {
+	// The Withings ID of the user.
+	UserID string
+
+	// The last time a call to Do completed without error for the user. It is zero if none has.
+	LastSync time.Time
+
+	// The error of the last call to Do that failed for the user, and when it failed. The error is not cleared by
+	// following successful calls, compare LastErrorAt to LastSync.
+	LastError   error
+	LastErrorAt time.Time
+}
+
+// UserManager manages the AuthorizedUser of every user of an application, keyed by Withings user ID. Users are loaded
+// from the TokenStore of the manager, which every refreshed token is saved to. Work for the users is run with Do, which
+// enforces the concurrency limits of the manager and tracks the outcome of the work.
+type UserManager struct {
+	c      *Client
+	store  TokenStore
+	opts   UserManagerOptions
+	global chan struct{}
+
+	mu    sync.Mutex
+	users map[string]*managedUser
+}
+
+// managedUser is a user managed by a UserManager.
+type managedUser struct {
+	u     *AuthorizedUser
+	sem   chan struct{}
+	state UserState
+}
+
+// NewUserManager returns a manager of the users whose tokens are stored in store. No user is loaded until requested
+// or LoadAll is called.
+func (c *Client) NewUserManager(store TokenStore, opts UserManagerOptions) *UserManager {
+	if opts.MaxConcurrencyPerUser < 1 {
+		opts.MaxConcurrencyPerUser = 1
+	}
+
+	m := &UserManager{
+		c:     c,
+		store: store,
+		opts:  opts,
+		users: make(map[string]*managedUser),
+	}
+	if opts.MaxConcurrency > 0 {
+		m.global = make(chan struct{}, opts.MaxConcurrency)
+	}
+
+	return m
+}
+
+// LoadAll loads every user stored in the token store of the manager. The store must implement TokenLister. Users
+// that are already managed are kept as is.
+func (m *UserManager) LoadAll(ctx context.Context) error {
+	lister, ok := m.store.(TokenLister)
+	if !ok {
+		return fmt.Errorf("token store does not support listing users")
+	}
+
+	userIDs, err := lister.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list users: %w", err)
+	}
+
+	for _, userID := range userIDs {
+		if _, err = m.User(ctx, userID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Add saves the token of a user that granted access to the token store and manages the user, replacing any user
+// managed with the same Withings user ID. ErrUnknownUser is returned if the user ID of the token is not known.
+func (m *UserManager) Add(ctx context.Context, token AccessToken) (*AuthorizedUser, error) {
+	if token.UserID == "" {
+		return nil, ErrUnknownUser
+	}
+
+	if err := m.store.Save(ctx, token.UserID, token); err != nil {
+		return nil, fmt.Errorf("failed to save token: %w", err)
+	}
+
+	// A previous token of the user must not be preferred over the token granted now.
+	m.c.tokens.forget(token.UserID)
+
+	mu := m.manage(m.c.NewAuthorizedUser(token, m.userOptions(token.UserID)...), true)
+	return mu.u, nil
+}
+
+// User returns the AuthorizedUser of the user with the Withings user ID provided, loading the user from the token
+// store if it is not managed yet. If no token is stored ErrTokenNotFound is returned.
+func (m *UserManager) User(ctx context.Context, userID string) (*AuthorizedUser, error) {
+	mu, err := m.managed(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return mu.u, nil
+}
+
+// Users returns the Withings user IDs of the managed users, sorted.
+func (m *UserManager) Users() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	userIDs := make([]string, 0, len(m.users))
+	for userID := range m.users {
+		userIDs = append(userIDs, userID)
+	}
+	sort.Strings(userIDs)
+	return userIDs
+}
+
+// State returns the state of the managed user with the Withings user ID provided. False is returned if the user is not
+// managed.
+func (m *UserManager) State(userID string) (UserState, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	mu, ok := m.users[userID]
+	if !ok {
+		return UserState{}, false
+	}
+	return mu.state, true
+}
+
+// Remove stops managing the user with the Withings user ID provided. The token of the user remains in the token store.
+func (m *UserManager) Remove(userID string) {
+	m.mu.Lock()
+	delete(m.users, userID)
+	m.mu.Unlock()
+
+	if m.opts.Refresher != nil {
+		m.opts.Refresher.Unregister(userID)
+	}
+}
+
+// Revoke revokes the access of the client to the data of the user with the Withings user ID provided, see
+// AuthorizedUser.Revoke, and stops managing the user. The user is loaded from the token store if it is not managed
+// yet. If revoking fails the user remains managed so Revoke can be called again.
+func (m *UserManager) Revoke(ctx context.Context, userID string) error {
+	u, err := m.User(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err = u.Revoke(ctx); err != nil {
+		return err
+	}
+	m.Remove(userID)
+
+	return nil
+}
+
+// Do calls fn with the AuthorizedUser of the user with the Withings user ID provided, loading the user from the token
+// store if needed. The call waits until it is within the concurrency limits of the manager or ctx ends. The outcome of
+// fn is recorded in the state of the user and returned.
+func (m *UserManager) Do(ctx context.Context, userID string, fn func(ctx context.Context, u *AuthorizedUser) error) error {
+	mu, err := m.managed(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err = acquire(ctx, mu.sem); err != nil {
+		return fmt.Errorf("failed waiting for user %s: %w", userID, err)
+	}
+	defer func() { <-mu.sem }()
+
+	if m.global != nil {
+		if err = acquire(ctx, m.global); err != nil {
+			return fmt.Errorf("failed waiting for user %s: %w", userID, err)
+		}
+		defer func() { <-m.global }()
+	}
+
+	err = fn(ctx, mu.u)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err != nil {
+		mu.state.LastError = err
+		mu.state.LastErrorAt = time.Now()
+	} else {
+		mu.state.LastSync = time.Now()
+	}
+
+	return err
+}
+
+// managed returns the managed user with the Withings user ID provided, loading the user from the token store if it
+// is not managed yet.
+func (m *UserManager) managed(ctx context.Context, userID string) (*managedUser, error) {
+	m.mu.Lock()
+	mu, ok := m.users[userID]
+	m.mu.Unlock()
+	if ok {
+		return mu, nil
+	}
+
+	u, err := m.c.LoadAuthorizedUser(ctx, m.store, userID, m.userOptions(userID)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.manage(u, false), nil
+}
+
+// manage adds the user to the managed users. An already managed user is kept unless replace is set.
+func (m *UserManager) manage(u *AuthorizedUser, replace bool) *managedUser {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if mu, ok := m.users[u.userID]; ok && !replace {
+		return mu
+	}
+
+	mu := &managedUser{
+		u:     u,
+		sem:   make(chan struct{}, m.opts.MaxConcurrencyPerUser),
+		state: UserState{UserID: u.userID},
+	}
+	m.users[u.userID] = mu
+
+	if m.opts.Refresher != nil {
+		// The user ID is known so registering cannot fail.
+		_ = m.opts.Refresher.Register(u)
+	}
+
+	return mu
+}
+
+// userOptions returns the options of the AuthorizedUser of the user.
+func (m *UserManager) userOptions(userID string) []AuthorizedUserOption {
+	return append(append([]AuthorizedUserOption{}, m.opts.UserOptions...), WithTokenStore(m.store, userID))
+}
+
+// acquire takes a slot of the semaphore, waiting until one is free or ctx ends.
+func acquire(ctx context.Context, sem chan struct{}) error {
+	select {
+	case sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,157 @@
+package withings_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jrmycanady/withings"
+	"github.com/jrmycanady/withings/withingstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserManager(t *testing.T) {
+	t.Parallel()
+
+	srv := withingstest.NewServer()
+	defer srv.Close()
+	c := srv.NewClient(url.URL{})
+	ctx := context.Background()
+
+	store := withings.NewMemoryTokenStore()
+	for _, userID := range []string{"1", "2", "3"} {
+		srv.AddUser(withingstest.NewDemoUser(userID, time.Now()))
+		require.Nil(t, store.Save(ctx, userID, srv.IssueToken(userID)))
+	}
+
+	t.Run("Loads users", func(t *testing.T) {
+		m := c.NewUserManager(store, withings.UserManagerOptions{})
+		require.Nil(t, m.LoadAll(ctx))
+		assert.Equal(t, []string{"1", "2", "3"}, m.Users())
+
+		u, err := m.User(ctx, "1")
+		require.Nil(t, err)
+		again, err := m.User(ctx, "1")
+		require.Nil(t, err)
+		assert.Same(t, u, again)
+		assert.Equal(t, "1", u.Token().UserID)
+
+		_, err = m.User(ctx, "4")
+		assert.ErrorIs(t, err, withings.ErrTokenNotFound)
+	})
+
+	t.Run("Limits concurrency", func(t *testing.T) {
+		m := c.NewUserManager(store, withings.UserManagerOptions{MaxConcurrency: 2})
+
+		var mu sync.Mutex
+		running := make(map[string]int)
+		var total, maxTotal, maxPerUser int
+
+		var wg sync.WaitGroup
+		for i := 0; i < 12; i++ {
+			wg.Add(1)
+			go func(userID string) {
+				defer wg.Done()
+
+				err := m.Do(ctx, userID, func(ctx context.Context, u *withings.AuthorizedUser) error {
+					mu.Lock()
+					running[userID]++
+					total++
+					if running[userID] > maxPerUser {
+						maxPerUser = running[userID]
+					}
+					if total > maxTotal {
+						maxTotal = total
+					}
+					mu.Unlock()
+
+					time.Sleep(5 * time.Millisecond)
+
+					mu.Lock()
+					running[userID]--
+					total--
+					mu.Unlock()
+					return nil
+				})
+				assert.Nil(t, err)
+			}(fmt.Sprint(i%3 + 1))
+		}
+		wg.Wait()
+
+		assert.Equal(t, 2, maxTotal)
+		assert.Equal(t, 1, maxPerUser)
+	})
+
+	t.Run("Tracks state", func(t *testing.T) {
+		m := c.NewUserManager(store, withings.UserManagerOptions{})
+		start := time.Now()
+
+		err := m.Do(ctx, "1", func(ctx context.Context, u *withings.AuthorizedUser) error {
+			_, err := u.API().GetMeasure(ctx, withings.GetMeasureParam{})
+			return err
+		})
+		require.Nil(t, err)
+
+		syncErr := errors.New("sync failed")
+		err = m.Do(ctx, "2", func(ctx context.Context, u *withings.AuthorizedUser) error {
+			return syncErr
+		})
+		assert.Equal(t, syncErr, err)
+
+		state, ok := m.State("1")
+		require.True(t, ok)
+		assert.Equal(t, "1", state.UserID)
+		assert.False(t, state.LastSync.Before(start))
+		assert.Nil(t, state.LastError)
+
+		state, ok = m.State("2")
+		require.True(t, ok)
+		assert.True(t, state.LastSync.IsZero())
+		assert.Equal(t, syncErr, state.LastError)
+		assert.False(t, state.LastErrorAt.Before(start))
+
+		_, ok = m.State("3")
+		assert.False(t, ok)
+	})
+
+	t.Run("Adds, removes and revokes users", func(t *testing.T) {
+		store := withings.NewMemoryTokenStore()
+		m := c.NewUserManager(store, withings.UserManagerOptions{})
+
+		token := srv.IssueToken("1")
+		token.ExpiresAt = time.Now().Add(-time.Minute)
+		_, err := m.Add(ctx, token)
+		require.Nil(t, err)
+		_, err = m.Add(ctx, srv.IssueToken("2"))
+		require.Nil(t, err)
+		assert.Equal(t, []string{"1", "2"}, m.Users())
+
+		// Refreshed tokens are saved to the store of the manager.
+		err = m.Do(ctx, "1", func(ctx context.Context, u *withings.AuthorizedUser) error {
+			_, err := u.API().GetMeasure(ctx, withings.GetMeasureParam{})
+			return err
+		})
+		require.Nil(t, err)
+		stored, err := store.Load(ctx, "1")
+		require.Nil(t, err)
+		assert.NotEqual(t, token.RefreshToken, stored.RefreshToken)
+
+		m.Remove("1")
+		assert.Equal(t, []string{"2"}, m.Users())
+		_, err = store.Load(ctx, "1")
+		assert.Nil(t, err)
+
+		require.Nil(t, m.Revoke(ctx, "2"))
+		assert.Empty(t, m.Users())
+		_, err = store.Load(ctx, "2")
+		assert.ErrorIs(t, err, withings.ErrTokenNotFound)
+
+		_, err = m.Add(ctx, withings.AccessToken{AccessToken: "access"})
+		assert.ErrorIs(t, err, withings.ErrUnknownUser)
+	})
+}
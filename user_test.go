@@ -0,0 +1,76 @@
+package withings_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jrmycanady/withings"
+	"github.com/jrmycanady/withings/withingstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_GetUserGoals(t *testing.T) {
+	t.Parallel()
+
+	srv := withingstest.NewServer()
+	defer srv.Close()
+	srv.AddUser(withingstest.NewDemoUser("1", time.Now()))
+	c := srv.NewClient(url.URL{})
+
+	resp, err := c.GetUserGoals(context.Background(), srv.IssueToken("1"))
+	require.Nil(t, err)
+	assert.Equal(t, int64(10000), resp.Body.Goals.Steps)
+	assert.Equal(t, 8*time.Hour, resp.Body.Goals.SleepDuration())
+	require.NotNil(t, resp.Body.Goals.Weight)
+	assert.InDelta(t, 75.5, resp.Body.Goals.Weight.DecimalValue(), 0.0001)
+}
+
+func TestGetUserGoalsResp_UnmarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		data   string
+		steps  int64
+		sleep  time.Duration
+		weight float64
+	}{
+		{
+			name:   "Every goal",
+			data:   `{"status":0,"body":{"goals":{"steps":8000,"sleep":27000,"weight":{"value":705,"unit":-1}}}}`,
+			steps:  8000,
+			sleep:  7*time.Hour + 30*time.Minute,
+			weight: 70.5,
+		},
+		{
+			name:  "Without weight",
+			data:  `{"status":0,"body":{"goals":{"steps":12000,"sleep":28800}}}`,
+			steps: 12000,
+			sleep: 8 * time.Hour,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var resp withings.GetUserGoalsResp
+			require.Nil(t, json.Unmarshal([]byte(tt.data), &resp))
+
+			goals := resp.Body.Goals
+			assert.Equal(t, tt.steps, goals.Steps)
+			assert.Equal(t, tt.sleep, goals.SleepDuration())
+			if tt.weight == 0 {
+				assert.Nil(t, goals.Weight)
+				return
+			}
+			require.NotNil(t, goals.Weight)
+			assert.InDelta(t, tt.weight, goals.Weight.DecimalValue(), 0.0001)
+		})
+	}
+}
@@ -0,0 +1,80 @@
+// Package withingsfake provides a fake implementation of the DataAPI and NotifyAPI interfaces of the withings package
+// for unit testing code that depends on them. The fake records every call and responds with canned responses or
+// functions configured per method.
+package withingsfake
+
+//go:generate go run gen.go
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/jrmycanady/withings"
+)
+
+// ErrNoResponse is returned by the methods of a Fake that have not been configured with a response.
+var ErrNoResponse = errors.New("no response configured")
+
+var (
+	_ withings.DataAPI   = (*Fake)(nil)
+	_ withings.NotifyAPI = (*Fake)(nil)
+)
+
+// Call is a call made to a Fake.
+type Call struct {
+	// The name of the method called.
+	Method string
+
+	// The arguments of the call, excluding the context.
+	Args []interface{}
+}
+
+// Fake is a fake implementation of withings.DataAPI and withings.NotifyAPI. Every method records its call and
+// responds with the function configured with the On method of the same name, or the response configured with the
+// Returns method. Methods without a configured response return ErrNoResponse. A Fake is safe for concurrent use.
+type Fake struct {
+	mu    sync.Mutex
+	calls []Call
+
+	responders
+}
+
+// New returns a fake without any configured responses.
+func New() *Fake {
+	return &Fake{}
+}
+
+// Calls returns every call made to the fake in order.
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]Call(nil), f.calls...)
+}
+
+// CallsTo returns the calls made to the method provided in order.
+func (f *Fake) CallsTo(method string) []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var calls []Call
+	for _, c := range f.calls {
+		if c.Method == method {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+// CallCount returns the number of calls made to the method provided.
+func (f *Fake) CallCount(method string) int {
+	return len(f.CallsTo(method))
+}
+
+// record records a call to the method with the arguments provided.
+func (f *Fake) record(method string, args ...interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls = append(f.calls, Call{Method: method, Args: args})
+}
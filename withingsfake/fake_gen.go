@@ -0,0 +1,565 @@
+// Code generated by gen.go; DO NOT EDIT.
+
+package withingsfake
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jrmycanady/withings"
+)
+
+// responders are the functions configured to respond to the calls of a Fake.
+type responders struct {
+	getActivity                func(ctx context.Context, param withings.GetActivityParam) (*withings.GetActivityResp, error)
+	getHeartHighFrequencyData  func(ctx context.Context, param withings.GetHeartHighFrequencyDataParam) (*withings.GetHeartHighFrequencyDataResp, error)
+	getHeartList               func(ctx context.Context, param withings.GetHeartListParam) (*withings.GetHeartResp, error)
+	getIntraDayActivity        func(ctx context.Context, param withings.GetIntraDayActivityParam) (*withings.GetIntraDayActivityResp, error)
+	getIntraDayActivityChunked func(ctx context.Context, param withings.GetIntraDayActivityParam, opts withings.ChunkOptions) (*withings.GetIntraDayActivityResp, error)
+	getMeasure                 func(ctx context.Context, param withings.GetMeasureParam) (*withings.GetMeasureResp, error)
+	getSleep                   func(ctx context.Context, param withings.GetSleepParam) (*withings.GetSleepResp, error)
+	getSleepChunked            func(ctx context.Context, param withings.GetSleepParam, opts withings.ChunkOptions) (*withings.GetSleepResp, error)
+	getSleepSummary            func(ctx context.Context, param withings.GetSleepSummaryParam) (*withings.GetSleepSummaryResp, error)
+	getStethoList              func(ctx context.Context, param withings.GetStethoListParam) (*withings.GetStethoListResp, error)
+	getStethoSignal            func(ctx context.Context, param withings.GetStethoSignalParam) (*withings.GetStethoSignalResp, error)
+	getUserDevice              func(ctx context.Context) (*withings.GetUserDeviceResp, error)
+	getUserGoals               func(ctx context.Context) (*withings.GetUserGoalsResp, error)
+	getWorkout                 func(ctx context.Context, param withings.GetWorkoutParam) (*withings.GetWorkoutResp, error)
+	getNotification            func(ctx context.Context, param withings.GetNotificationParam) (*withings.GetNotificationResp, error)
+	listNotification           func(ctx context.Context, param withings.ListNotificationParam) (*withings.ListNotificationResp, error)
+	revokeNotification         func(ctx context.Context, param withings.RevokeNotificationParam) (*withings.RevokeNotificationResp, error)
+	subscribeToNotifications   func(ctx context.Context, param withings.SubscribeToNotificationsParam) (*withings.SubscribeToNotificationsResp, error)
+	updateNotification         func(ctx context.Context, param withings.UpdateNotificationParam) (*withings.UpdateNotificationResp, error)
+}
+
+// GetActivity records the call and responds with the configured response.
+func (f *Fake) GetActivity(ctx context.Context, param withings.GetActivityParam) (*withings.GetActivityResp, error) {
+	f.record("GetActivity", param)
+
+	f.mu.Lock()
+	fn := f.getActivity
+	f.mu.Unlock()
+	if fn == nil {
+		return nil, fmt.Errorf("%w: GetActivity", ErrNoResponse)
+	}
+	return fn(ctx, param)
+}
+
+// OnGetActivity configures GetActivity to respond with fn.
+func (f *Fake) OnGetActivity(fn func(ctx context.Context, param withings.GetActivityParam) (*withings.GetActivityResp, error)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.getActivity = fn
+}
+
+// GetActivityReturns configures GetActivity to respond with resp and err.
+func (f *Fake) GetActivityReturns(resp *withings.GetActivityResp, err error) {
+	f.OnGetActivity(func(ctx context.Context, param withings.GetActivityParam) (*withings.GetActivityResp, error) {
+		return resp, err
+	})
+}
+
+// GetHeartHighFrequencyData records the call and responds with the configured response.
+func (f *Fake) GetHeartHighFrequencyData(ctx context.Context, param withings.GetHeartHighFrequencyDataParam) (*withings.GetHeartHighFrequencyDataResp, error) {
+	f.record("GetHeartHighFrequencyData", param)
+
+	f.mu.Lock()
+	fn := f.getHeartHighFrequencyData
+	f.mu.Unlock()
+	if fn == nil {
+		return nil, fmt.Errorf("%w: GetHeartHighFrequencyData", ErrNoResponse)
+	}
+	return fn(ctx, param)
+}
+
+// OnGetHeartHighFrequencyData configures GetHeartHighFrequencyData to respond with fn.
+func (f *Fake) OnGetHeartHighFrequencyData(fn func(ctx context.Context, param withings.GetHeartHighFrequencyDataParam) (*withings.GetHeartHighFrequencyDataResp, error)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.getHeartHighFrequencyData = fn
+}
+
+// GetHeartHighFrequencyDataReturns configures GetHeartHighFrequencyData to respond with resp and err.
+func (f *Fake) GetHeartHighFrequencyDataReturns(resp *withings.GetHeartHighFrequencyDataResp, err error) {
+	f.OnGetHeartHighFrequencyData(func(ctx context.Context, param withings.GetHeartHighFrequencyDataParam) (*withings.GetHeartHighFrequencyDataResp, error) {
+		return resp, err
+	})
+}
+
+// GetHeartList records the call and responds with the configured response.
+func (f *Fake) GetHeartList(ctx context.Context, param withings.GetHeartListParam) (*withings.GetHeartResp, error) {
+	f.record("GetHeartList", param)
+
+	f.mu.Lock()
+	fn := f.getHeartList
+	f.mu.Unlock()
+	if fn == nil {
+		return nil, fmt.Errorf("%w: GetHeartList", ErrNoResponse)
+	}
+	return fn(ctx, param)
+}
+
+// OnGetHeartList configures GetHeartList to respond with fn.
+func (f *Fake) OnGetHeartList(fn func(ctx context.Context, param withings.GetHeartListParam) (*withings.GetHeartResp, error)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.getHeartList = fn
+}
+
+// GetHeartListReturns configures GetHeartList to respond with resp and err.
+func (f *Fake) GetHeartListReturns(resp *withings.GetHeartResp, err error) {
+	f.OnGetHeartList(func(ctx context.Context, param withings.GetHeartListParam) (*withings.GetHeartResp, error) {
+		return resp, err
+	})
+}
+
+// GetIntraDayActivity records the call and responds with the configured response.
+func (f *Fake) GetIntraDayActivity(ctx context.Context, param withings.GetIntraDayActivityParam) (*withings.GetIntraDayActivityResp, error) {
+	f.record("GetIntraDayActivity", param)
+
+	f.mu.Lock()
+	fn := f.getIntraDayActivity
+	f.mu.Unlock()
+	if fn == nil {
+		return nil, fmt.Errorf("%w: GetIntraDayActivity", ErrNoResponse)
+	}
+	return fn(ctx, param)
+}
+
+// OnGetIntraDayActivity configures GetIntraDayActivity to respond with fn.
+func (f *Fake) OnGetIntraDayActivity(fn func(ctx context.Context, param withings.GetIntraDayActivityParam) (*withings.GetIntraDayActivityResp, error)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.getIntraDayActivity = fn
+}
+
+// GetIntraDayActivityReturns configures GetIntraDayActivity to respond with resp and err.
+func (f *Fake) GetIntraDayActivityReturns(resp *withings.GetIntraDayActivityResp, err error) {
+	f.OnGetIntraDayActivity(func(ctx context.Context, param withings.GetIntraDayActivityParam) (*withings.GetIntraDayActivityResp, error) {
+		return resp, err
+	})
+}
+
+// GetIntraDayActivityChunked records the call and responds with the configured response.
+func (f *Fake) GetIntraDayActivityChunked(ctx context.Context, param withings.GetIntraDayActivityParam, opts withings.ChunkOptions) (*withings.GetIntraDayActivityResp, error) {
+	f.record("GetIntraDayActivityChunked", param, opts)
+
+	f.mu.Lock()
+	fn := f.getIntraDayActivityChunked
+	f.mu.Unlock()
+	if fn == nil {
+		return nil, fmt.Errorf("%w: GetIntraDayActivityChunked", ErrNoResponse)
+	}
+	return fn(ctx, param, opts)
+}
+
+// OnGetIntraDayActivityChunked configures GetIntraDayActivityChunked to respond with fn.
+func (f *Fake) OnGetIntraDayActivityChunked(fn func(ctx context.Context, param withings.GetIntraDayActivityParam, opts withings.ChunkOptions) (*withings.GetIntraDayActivityResp, error)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.getIntraDayActivityChunked = fn
+}
+
+// GetIntraDayActivityChunkedReturns configures GetIntraDayActivityChunked to respond with resp and err.
+func (f *Fake) GetIntraDayActivityChunkedReturns(resp *withings.GetIntraDayActivityResp, err error) {
+	f.OnGetIntraDayActivityChunked(func(ctx context.Context, param withings.GetIntraDayActivityParam, opts withings.ChunkOptions) (*withings.GetIntraDayActivityResp, error) {
+		return resp, err
+	})
+}
+
+// GetMeasure records the call and responds with the configured response.
+func (f *Fake) GetMeasure(ctx context.Context, param withings.GetMeasureParam) (*withings.GetMeasureResp, error) {
+	f.record("GetMeasure", param)
+
+	f.mu.Lock()
+	fn := f.getMeasure
+	f.mu.Unlock()
+	if fn == nil {
+		return nil, fmt.Errorf("%w: GetMeasure", ErrNoResponse)
+	}
+	return fn(ctx, param)
+}
+
+// OnGetMeasure configures GetMeasure to respond with fn.
+func (f *Fake) OnGetMeasure(fn func(ctx context.Context, param withings.GetMeasureParam) (*withings.GetMeasureResp, error)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.getMeasure = fn
+}
+
+// GetMeasureReturns configures GetMeasure to respond with resp and err.
+func (f *Fake) GetMeasureReturns(resp *withings.GetMeasureResp, err error) {
+	f.OnGetMeasure(func(ctx context.Context, param withings.GetMeasureParam) (*withings.GetMeasureResp, error) {
+		return resp, err
+	})
+}
+
+// GetSleep records the call and responds with the configured response.
+func (f *Fake) GetSleep(ctx context.Context, param withings.GetSleepParam) (*withings.GetSleepResp, error) {
+	f.record("GetSleep", param)
+
+	f.mu.Lock()
+	fn := f.getSleep
+	f.mu.Unlock()
+	if fn == nil {
+		return nil, fmt.Errorf("%w: GetSleep", ErrNoResponse)
+	}
+	return fn(ctx, param)
+}
+
+// OnGetSleep configures GetSleep to respond with fn.
+func (f *Fake) OnGetSleep(fn func(ctx context.Context, param withings.GetSleepParam) (*withings.GetSleepResp, error)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.getSleep = fn
+}
+
+// GetSleepReturns configures GetSleep to respond with resp and err.
+func (f *Fake) GetSleepReturns(resp *withings.GetSleepResp, err error) {
+	f.OnGetSleep(func(ctx context.Context, param withings.GetSleepParam) (*withings.GetSleepResp, error) {
+		return resp, err
+	})
+}
+
+// GetSleepChunked records the call and responds with the configured response.
+func (f *Fake) GetSleepChunked(ctx context.Context, param withings.GetSleepParam, opts withings.ChunkOptions) (*withings.GetSleepResp, error) {
+	f.record("GetSleepChunked", param, opts)
+
+	f.mu.Lock()
+	fn := f.getSleepChunked
+	f.mu.Unlock()
+	if fn == nil {
+		return nil, fmt.Errorf("%w: GetSleepChunked", ErrNoResponse)
+	}
+	return fn(ctx, param, opts)
+}
+
+// OnGetSleepChunked configures GetSleepChunked to respond with fn.
+func (f *Fake) OnGetSleepChunked(fn func(ctx context.Context, param withings.GetSleepParam, opts withings.ChunkOptions) (*withings.GetSleepResp, error)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.getSleepChunked = fn
+}
+
+// GetSleepChunkedReturns configures GetSleepChunked to respond with resp and err.
+func (f *Fake) GetSleepChunkedReturns(resp *withings.GetSleepResp, err error) {
+	f.OnGetSleepChunked(func(ctx context.Context, param withings.GetSleepParam, opts withings.ChunkOptions) (*withings.GetSleepResp, error) {
+		return resp, err
+	})
+}
+
+// GetSleepSummary records the call and responds with the configured response.
+func (f *Fake) GetSleepSummary(ctx context.Context, param withings.GetSleepSummaryParam) (*withings.GetSleepSummaryResp, error) {
+	f.record("GetSleepSummary", param)
+
+	f.mu.Lock()
+	fn := f.getSleepSummary
+	f.mu.Unlock()
+	if fn == nil {
+		return nil, fmt.Errorf("%w: GetSleepSummary", ErrNoResponse)
+	}
+	return fn(ctx, param)
+}
+
+// OnGetSleepSummary configures GetSleepSummary to respond with fn.
+func (f *Fake) OnGetSleepSummary(fn func(ctx context.Context, param withings.GetSleepSummaryParam) (*withings.GetSleepSummaryResp, error)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.getSleepSummary = fn
+}
+
+// GetSleepSummaryReturns configures GetSleepSummary to respond with resp and err.
+func (f *Fake) GetSleepSummaryReturns(resp *withings.GetSleepSummaryResp, err error) {
+	f.OnGetSleepSummary(func(ctx context.Context, param withings.GetSleepSummaryParam) (*withings.GetSleepSummaryResp, error) {
+		return resp, err
+	})
+}
+
+// GetStethoList records the call and responds with the configured response.
+func (f *Fake) GetStethoList(ctx context.Context, param withings.GetStethoListParam) (*withings.GetStethoListResp, error) {
+	f.record("GetStethoList", param)
+
+	f.mu.Lock()
+	fn := f.getStethoList
+	f.mu.Unlock()
+	if fn == nil {
+		return nil, fmt.Errorf("%w: GetStethoList", ErrNoResponse)
+	}
+	return fn(ctx, param)
+}
+
+// OnGetStethoList configures GetStethoList to respond with fn.
+func (f *Fake) OnGetStethoList(fn func(ctx context.Context, param withings.GetStethoListParam) (*withings.GetStethoListResp, error)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.getStethoList = fn
+}
+
+// GetStethoListReturns configures GetStethoList to respond with resp and err.
+func (f *Fake) GetStethoListReturns(resp *withings.GetStethoListResp, err error) {
+	f.OnGetStethoList(func(ctx context.Context, param withings.GetStethoListParam) (*withings.GetStethoListResp, error) {
+		return resp, err
+	})
+}
+
+// GetStethoSignal records the call and responds with the configured response.
+func (f *Fake) GetStethoSignal(ctx context.Context, param withings.GetStethoSignalParam) (*withings.GetStethoSignalResp, error) {
+	f.record("GetStethoSignal", param)
+
+	f.mu.Lock()
+	fn := f.getStethoSignal
+	f.mu.Unlock()
+	if fn == nil {
+		return nil, fmt.Errorf("%w: GetStethoSignal", ErrNoResponse)
+	}
+	return fn(ctx, param)
+}
+
+// OnGetStethoSignal configures GetStethoSignal to respond with fn.
+func (f *Fake) OnGetStethoSignal(fn func(ctx context.Context, param withings.GetStethoSignalParam) (*withings.GetStethoSignalResp, error)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.getStethoSignal = fn
+}
+
+// GetStethoSignalReturns configures GetStethoSignal to respond with resp and err.
+func (f *Fake) GetStethoSignalReturns(resp *withings.GetStethoSignalResp, err error) {
+	f.OnGetStethoSignal(func(ctx context.Context, param withings.GetStethoSignalParam) (*withings.GetStethoSignalResp, error) {
+		return resp, err
+	})
+}
+
+// GetUserDevice records the call and responds with the configured response.
+func (f *Fake) GetUserDevice(ctx context.Context) (*withings.GetUserDeviceResp, error) {
+	f.record("GetUserDevice")
+
+	f.mu.Lock()
+	fn := f.getUserDevice
+	f.mu.Unlock()
+	if fn == nil {
+		return nil, fmt.Errorf("%w: GetUserDevice", ErrNoResponse)
+	}
+	return fn(ctx)
+}
+
+// OnGetUserDevice configures GetUserDevice to respond with fn.
+func (f *Fake) OnGetUserDevice(fn func(ctx context.Context) (*withings.GetUserDeviceResp, error)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.getUserDevice = fn
+}
+
+// GetUserDeviceReturns configures GetUserDevice to respond with resp and err.
+func (f *Fake) GetUserDeviceReturns(resp *withings.GetUserDeviceResp, err error) {
+	f.OnGetUserDevice(func(ctx context.Context) (*withings.GetUserDeviceResp, error) {
+		return resp, err
+	})
+}
+
+// GetUserGoals records the call and responds with the configured response.
+func (f *Fake) GetUserGoals(ctx context.Context) (*withings.GetUserGoalsResp, error) {
+	f.record("GetUserGoals")
+
+	f.mu.Lock()
+	fn := f.getUserGoals
+	f.mu.Unlock()
+	if fn == nil {
+		return nil, fmt.Errorf("%w: GetUserGoals", ErrNoResponse)
+	}
+	return fn(ctx)
+}
+
+// OnGetUserGoals configures GetUserGoals to respond with fn.
+func (f *Fake) OnGetUserGoals(fn func(ctx context.Context) (*withings.GetUserGoalsResp, error)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.getUserGoals = fn
+}
+
+// GetUserGoalsReturns configures GetUserGoals to respond with resp and err.
+func (f *Fake) GetUserGoalsReturns(resp *withings.GetUserGoalsResp, err error) {
+	f.OnGetUserGoals(func(ctx context.Context) (*withings.GetUserGoalsResp, error) {
+		return resp, err
+	})
+}
+
+// GetWorkout records the call and responds with the configured response.
+func (f *Fake) GetWorkout(ctx context.Context, param withings.GetWorkoutParam) (*withings.GetWorkoutResp, error) {
+	f.record("GetWorkout", param)
+
+	f.mu.Lock()
+	fn := f.getWorkout
+	f.mu.Unlock()
+	if fn == nil {
+		return nil, fmt.Errorf("%w: GetWorkout", ErrNoResponse)
+	}
+	return fn(ctx, param)
+}
+
+// OnGetWorkout configures GetWorkout to respond with fn.
+func (f *Fake) OnGetWorkout(fn func(ctx context.Context, param withings.GetWorkoutParam) (*withings.GetWorkoutResp, error)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.getWorkout = fn
+}
+
+// GetWorkoutReturns configures GetWorkout to respond with resp and err.
+func (f *Fake) GetWorkoutReturns(resp *withings.GetWorkoutResp, err error) {
+	f.OnGetWorkout(func(ctx context.Context, param withings.GetWorkoutParam) (*withings.GetWorkoutResp, error) {
+		return resp, err
+	})
+}
+
+// GetNotification records the call and responds with the configured response.
+func (f *Fake) GetNotification(ctx context.Context, param withings.GetNotificationParam) (*withings.GetNotificationResp, error) {
+	f.record("GetNotification", param)
+
+	f.mu.Lock()
+	fn := f.getNotification
+	f.mu.Unlock()
+	if fn == nil {
+		return nil, fmt.Errorf("%w: GetNotification", ErrNoResponse)
+	}
+	return fn(ctx, param)
+}
+
+// OnGetNotification configures GetNotification to respond with fn.
+func (f *Fake) OnGetNotification(fn func(ctx context.Context, param withings.GetNotificationParam) (*withings.GetNotificationResp, error)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.getNotification = fn
+}
+
+// GetNotificationReturns configures GetNotification to respond with resp and err.
+func (f *Fake) GetNotificationReturns(resp *withings.GetNotificationResp, err error) {
+	f.OnGetNotification(func(ctx context.Context, param withings.GetNotificationParam) (*withings.GetNotificationResp, error) {
+		return resp, err
+	})
+}
+
+// ListNotification records the call and responds with the configured response.
+func (f *Fake) ListNotification(ctx context.Context, param withings.ListNotificationParam) (*withings.ListNotificationResp, error) {
+	f.record("ListNotification", param)
+
+	f.mu.Lock()
+	fn := f.listNotification
+	f.mu.Unlock()
+	if fn == nil {
+		return nil, fmt.Errorf("%w: ListNotification", ErrNoResponse)
+	}
+	return fn(ctx, param)
+}
+
+// OnListNotification configures ListNotification to respond with fn.
+func (f *Fake) OnListNotification(fn func(ctx context.Context, param withings.ListNotificationParam) (*withings.ListNotificationResp, error)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.listNotification = fn
+}
+
+// ListNotificationReturns configures ListNotification to respond with resp and err.
+func (f *Fake) ListNotificationReturns(resp *withings.ListNotificationResp, err error) {
+	f.OnListNotification(func(ctx context.Context, param withings.ListNotificationParam) (*withings.ListNotificationResp, error) {
+		return resp, err
+	})
+}
+
+// RevokeNotification records the call and responds with the configured response.
+func (f *Fake) RevokeNotification(ctx context.Context, param withings.RevokeNotificationParam) (*withings.RevokeNotificationResp, error) {
+	f.record("RevokeNotification", param)
+
+	f.mu.Lock()
+	fn := f.revokeNotification
+	f.mu.Unlock()
+	if fn == nil {
+		return nil, fmt.Errorf("%w: RevokeNotification", ErrNoResponse)
+	}
+	return fn(ctx, param)
+}
+
+// OnRevokeNotification configures RevokeNotification to respond with fn.
+func (f *Fake) OnRevokeNotification(fn func(ctx context.Context, param withings.RevokeNotificationParam) (*withings.RevokeNotificationResp, error)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.revokeNotification = fn
+}
+
+// RevokeNotificationReturns configures RevokeNotification to respond with resp and err.
+func (f *Fake) RevokeNotificationReturns(resp *withings.RevokeNotificationResp, err error) {
+	f.OnRevokeNotification(func(ctx context.Context, param withings.RevokeNotificationParam) (*withings.RevokeNotificationResp, error) {
+		return resp, err
+	})
+}
+
+// SubscribeToNotifications records the call and responds with the configured response.
+func (f *Fake) SubscribeToNotifications(ctx context.Context, param withings.SubscribeToNotificationsParam) (*withings.SubscribeToNotificationsResp, error) {
+	f.record("SubscribeToNotifications", param)
+
+	f.mu.Lock()
+	fn := f.subscribeToNotifications
+	f.mu.Unlock()
+	if fn == nil {
+		return nil, fmt.Errorf("%w: SubscribeToNotifications", ErrNoResponse)
+	}
+	return fn(ctx, param)
+}
+
+// OnSubscribeToNotifications configures SubscribeToNotifications to respond with fn.
+func (f *Fake) OnSubscribeToNotifications(fn func(ctx context.Context, param withings.SubscribeToNotificationsParam) (*withings.SubscribeToNotificationsResp, error)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.subscribeToNotifications = fn
+}
+
+// SubscribeToNotificationsReturns configures SubscribeToNotifications to respond with resp and err.
+func (f *Fake) SubscribeToNotificationsReturns(resp *withings.SubscribeToNotificationsResp, err error) {
+	f.OnSubscribeToNotifications(func(ctx context.Context, param withings.SubscribeToNotificationsParam) (*withings.SubscribeToNotificationsResp, error) {
+		return resp, err
+	})
+}
+
+// UpdateNotification records the call and responds with the configured response.
+func (f *Fake) UpdateNotification(ctx context.Context, param withings.UpdateNotificationParam) (*withings.UpdateNotificationResp, error) {
+	f.record("UpdateNotification", param)
+
+	f.mu.Lock()
+	fn := f.updateNotification
+	f.mu.Unlock()
+	if fn == nil {
+		return nil, fmt.Errorf("%w: UpdateNotification", ErrNoResponse)
+	}
+	return fn(ctx, param)
+}
+
+// OnUpdateNotification configures UpdateNotification to respond with fn.
+func (f *Fake) OnUpdateNotification(fn func(ctx context.Context, param withings.UpdateNotificationParam) (*withings.UpdateNotificationResp, error)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.updateNotification = fn
+}
+
+// UpdateNotificationReturns configures UpdateNotification to respond with resp and err.
+func (f *Fake) UpdateNotificationReturns(resp *withings.UpdateNotificationResp, err error) {
+	f.OnUpdateNotification(func(ctx context.Context, param withings.UpdateNotificationParam) (*withings.UpdateNotificationResp, error) {
+		return resp, err
+	})
+}
@@ -0,0 +1,71 @@
+package withingsfake_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/jrmycanady/withings"
+	"github.com/jrmycanady/withings/withingsfake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFake(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	f := withingsfake.New()
+	var data withings.DataAPI = f
+	var notify withings.NotifyAPI = f
+
+	t.Run("Returns canned responses", func(t *testing.T) {
+		measures := &withings.GetMeasureResp{Body: withings.GetMeasureBody{Timezone: "Europe/Paris"}}
+		f.GetMeasureReturns(measures, nil)
+		failure := errors.New("failure")
+		f.ListNotificationReturns(nil, failure)
+
+		resp, err := data.GetMeasure(ctx, withings.GetMeasureParam{Offset: 1})
+		require.Nil(t, err)
+		assert.Same(t, measures, resp)
+
+		_, err = notify.ListNotification(ctx, withings.ListNotificationParam{Appli: 1})
+		assert.Equal(t, failure, err)
+	})
+
+	t.Run("Responds with functions", func(t *testing.T) {
+		f.OnGetSleepChunked(func(ctx context.Context, param withings.GetSleepParam, opts withings.ChunkOptions) (*withings.GetSleepResp, error) {
+			return &withings.GetSleepResp{Body: withings.GetSleepBody{Offset: int64(opts.Concurrency)}}, nil
+		})
+
+		resp, err := data.GetSleepChunked(ctx, withings.GetSleepParam{}, withings.ChunkOptions{Concurrency: 3})
+		require.Nil(t, err)
+		assert.Equal(t, int64(3), resp.Body.Offset)
+	})
+
+	t.Run("Fails without a response", func(t *testing.T) {
+		_, err := data.GetUserDevice(ctx)
+		assert.ErrorIs(t, err, withingsfake.ErrNoResponse)
+	})
+
+	t.Run("Records calls", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _ = data.GetMeasure(ctx, withings.GetMeasureParam{})
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, 11, f.CallCount("GetMeasure"))
+		assert.Equal(t, []interface{}{withings.GetMeasureParam{Offset: 1}}, f.CallsTo("GetMeasure")[0].Args)
+
+		calls := f.Calls()
+		require.Len(t, calls, 14)
+		assert.Equal(t, withingsfake.Call{Method: "ListNotification", Args: []interface{}{withings.ListNotificationParam{Appli: 1}}}, calls[1])
+		assert.Equal(t, withingsfake.Call{Method: "GetUserDevice"}, calls[3])
+	})
+}
@@ -0,0 +1,154 @@
+//go:build ignore
+// +build ignore
+
+// This program generates fake_gen.go from the method sets of the DataAPI and NotifyAPI interfaces. It is run by go
+// generate.
+package main
+
+import (
+	"bytes"
+	"go/format"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/jrmycanady/withings"
+)
+
+// method is a method of the interfaces to generate the fake implementation of.
+type method struct {
+	Name   string
+	Params []param
+	Result string
+}
+
+// param is a parameter of a method other than the context.
+type param struct {
+	Name string
+	Type string
+}
+
+// Field returns the name of the field of the function configured for the method.
+func (m method) Field() string {
+	return string(unicode.ToLower(rune(m.Name[0]))) + m.Name[1:]
+}
+
+// Signature returns the parameters of the method including the context.
+func (m method) Signature() string {
+	params := []string{"ctx context.Context"}
+	for _, p := range m.Params {
+		params = append(params, p.Name+" "+p.Type)
+	}
+	return strings.Join(params, ", ")
+}
+
+// Args returns the arguments passed on to the configured function.
+func (m method) Args() string {
+	args := []string{"ctx"}
+	for _, p := range m.Params {
+		args = append(args, p.Name)
+	}
+	return strings.Join(args, ", ")
+}
+
+// Recorded returns the arguments recorded for a call.
+func (m method) Recorded() string {
+	var args []string
+	for _, p := range m.Params {
+		args = append(args, p.Name)
+	}
+	return strings.Join(args, ", ")
+}
+
+var fakeTemplate = template.Must(template.New("fake").Parse(`// Code generated by gen.go; DO NOT EDIT.
+
+package withingsfake
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jrmycanady/withings"
+)
+
+// responders are the functions configured to respond to the calls of a Fake.
+type responders struct {
+{{- range .}}
+	{{.Field}} func({{.Signature}}) ({{.Result}}, error)
+{{- end}}
+}
+{{range .}}
+// {{.Name}} records the call and responds with the configured response.
+func (f *Fake) {{.Name}}({{.Signature}}) ({{.Result}}, error) {
+	f.record("{{.Name}}"{{if .Recorded}}, {{.Recorded}}{{end}})
+
+	f.mu.Lock()
+	fn := f.{{.Field}}
+	f.mu.Unlock()
+	if fn == nil {
+		return nil, fmt.Errorf("%w: {{.Name}}", ErrNoResponse)
+	}
+	return fn({{.Args}})
+}
+
+// On{{.Name}} configures {{.Name}} to respond with fn.
+func (f *Fake) On{{.Name}}(fn func({{.Signature}}) ({{.Result}}, error)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.{{.Field}} = fn
+}
+
+// {{.Name}}Returns configures {{.Name}} to respond with resp and err.
+func (f *Fake) {{.Name}}Returns(resp {{.Result}}, err error) {
+	f.On{{.Name}}(func({{.Signature}}) ({{.Result}}, error) {
+		return resp, err
+	})
+}
+{{end}}`))
+
+func main() {
+	var methods []method
+	for _, iface := range []reflect.Type{
+		reflect.TypeOf((*withings.DataAPI)(nil)).Elem(),
+		reflect.TypeOf((*withings.NotifyAPI)(nil)).Elem(),
+	} {
+		for i := 0; i < iface.NumMethod(); i++ {
+			m := iface.Method(i)
+
+			// Every method takes a context first and returns a response and an error.
+			var params []param
+			for j := 1; j < m.Type.NumIn(); j++ {
+				t := m.Type.In(j)
+				name := "param"
+				if t == reflect.TypeOf(withings.ChunkOptions{}) {
+					name = "opts"
+				}
+				params = append(params, param{Name: name, Type: t.String()})
+			}
+
+			methods = append(methods, method{
+				Name:   m.Name,
+				Params: params,
+				Result: m.Type.Out(0).String(),
+			})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := fakeTemplate.Execute(&buf, methods); err != nil {
+		log.Fatalf("failed to execute template: %s", err)
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatalf("failed to format source: %s", err)
+	}
+
+	if err = os.WriteFile("fake_gen.go", src, 0644); err != nil {
+		log.Fatalf("failed to write fake: %s", err)
+	}
+}
@@ -0,0 +1,19 @@
+module github.com/jrmycanady/withings/withingsotel
+
+go 1.17
+
+replace github.com/jrmycanady/withings => ../
+
+require (
+	github.com/jrmycanady/withings v0.0.0
+	go.opentelemetry.io/otel v1.10.0
+	go.opentelemetry.io/otel/sdk v1.10.0
+	go.opentelemetry.io/otel/trace v1.10.0
+)
+
+require (
+	github.com/go-logr/logr v1.2.3 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	golang.org/x/crypto v0.0.0-20220214200702-86341886e292 // indirect
+	golang.org/x/sys v0.0.0-20210615035016-665e8c7367d1 // indirect
+)
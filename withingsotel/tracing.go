@@ -0,0 +1,197 @@
+// Package withingsotel traces the API calls of a withings Client with OpenTelemetry. It is a separate module so the
+// withings package does not depend on OpenTelemetry unless tracing is used.
+package withingsotel
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/jrmycanady/withings"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the name of the tracer spans are started with.
+const TracerName = "github.com/jrmycanady/withings/withingsotel"
+
+// Keys of the attributes set on every span.
+const (
+	AttributeEndpoint = attribute.Key("withings.endpoint")
+	AttributeAction   = attribute.Key("withings.action")
+	AttributeStatus   = attribute.Key("withings.status")
+	AttributeOffset   = attribute.Key("withings.offset")
+	AttributeAttempt  = attribute.Key("withings.attempt")
+)
+
+// config is the configuration of the tracing middleware.
+type config struct {
+	provider trace.TracerProvider
+}
+
+// Option is an option that can be applied to the tracing middleware.
+type Option func(c *config)
+
+// WithTracerProvider configures the middleware to start spans with the provider provided. By default the global
+// provider is used.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *config) {
+		c.provider = provider
+	}
+}
+
+// Middleware returns a middleware starting a span for every API call, named after the method of the client making
+// the call, such as GetMeasure or RefreshAccessToken. The span is a child of the span in the context of the call and
+// is propagated to the middlewares and HTTP client below it. The endpoint, action, Withings status, page offset and
+// attempt are recorded as attributes. The middleware should be added with withings.WithMiddleware so the span covers
+// every attempt of a retried call, in which case the attempt attribute is that of the last attempt.
+func Middleware(opts ...Option) withings.Middleware {
+	cfg := config{
+		provider: otel.GetTracerProvider(),
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	tracer := cfg.provider.Tracer(TracerName)
+
+	return func(next withings.RoundTripFunc) withings.RoundTripFunc {
+		return func(call *withings.Call) (*withings.CallResponse, error) {
+			params := call.Params()
+
+			attrs := []attribute.KeyValue{
+				AttributeEndpoint.String(call.Endpoint),
+				AttributeAction.String(call.Action),
+			}
+			if offset, err := strconv.Atoi(params.Get("offset")); err == nil {
+				attrs = append(attrs, AttributeOffset.Int(offset))
+			}
+
+			ctx, span := tracer.Start(call.Context(), SpanName(call.Endpoint, params),
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(attrs...),
+			)
+			defer span.End()
+			call.Request = call.Request.WithContext(ctx)
+
+			resp, err := next(call)
+
+			span.SetAttributes(AttributeAttempt.Int(call.Attempt))
+			if resp != nil {
+				span.SetAttributes(
+					AttributeStatus.Int64(resp.Status),
+					semconv.HTTPStatusCodeKey.Int(resp.HTTPStatus),
+				)
+			}
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+
+			return resp, err
+		}
+	}
+}
+
+// operations maps the services and actions of the API to the method of the client calling them. Services are
+// matched against the end of the endpoint so the longest paths must come first.
+var operations = []struct {
+	path    string
+	actions map[string]string
+}{
+	{
+		path: withings.PathOAuth2,
+		actions: map[string]string{
+			"authorization_code":     "GetUserAccessToken",
+			"refresh_token":          "RefreshAccessToken",
+			withings.APIActionRevoke: "RevokeUser",
+		},
+	},
+	{
+		path: withings.PathMeasureV2,
+		actions: map[string]string{
+			withings.APIActionGetActivity:         "GetActivity",
+			withings.APIActionGetIntraDayActivity: "GetIntraDayActivity",
+			withings.APIActionGetWorkout:          "GetWorkout",
+		},
+	},
+	{
+		path: withings.PathMeasure,
+		actions: map[string]string{
+			withings.APIActionGetMeasure: "GetMeasure",
+		},
+	},
+	{
+		path: withings.PathHeartV2,
+		actions: map[string]string{
+			withings.APIActionGetHeartList: "GetHeartList",
+			withings.APIActionGetHeartGet:  "GetHeartHighFrequencyData",
+		},
+	},
+	{
+		path: withings.PathSleepV2,
+		actions: map[string]string{
+			withings.APIActionGetSleep:        "GetSleep",
+			withings.APIActionGetSleepSummary: "GetSleepSummary",
+		},
+	},
+	{
+		path: withings.PathStethoV2,
+		actions: map[string]string{
+			withings.APIActionGetStethoList:   "GetStethoList",
+			withings.APIActionGetStethoSignal: "GetStethoSignal",
+		},
+	},
+	{
+		path: withings.PathNotify,
+		actions: map[string]string{
+			withings.APIActionNotificationSubscribe: "SubscribeToNotifications",
+			withings.APIActionNotificationGet:       "GetNotification",
+			withings.APIActionNotificationList:      "ListNotification",
+			withings.APIActionNotificationRevoke:    "RevokeNotification",
+			withings.APIActionNotificationUpdate:    "UpdateNotification",
+		},
+	},
+	{
+		path: withings.PathUserV2,
+		actions: map[string]string{
+			withings.APIActionUserGetDevice: "GetUserDevice",
+			withings.APIActionUserGetGoals:  "GetUserGoals",
+		},
+	},
+	{
+		path: withings.PathSignatureV2,
+		actions: map[string]string{
+			withings.APIActionGetNonce: "GetNonce",
+		},
+	},
+}
+
+// SpanName returns the name of the span of a call to the endpoint with the parameters provided. This is the name of
+// the client method making the call. Calls not made by a known method are named after the endpoint and action.
+func SpanName(endpoint string, params url.Values) string {
+	action := params.Get("action")
+	for _, op := range operations {
+		if !strings.HasSuffix(endpoint, op.path) {
+			continue
+		}
+
+		// Token requests share an action and are told apart by their grant type.
+		key := action
+		if grantType := params.Get("grant_type"); op.path == withings.PathOAuth2 && grantType != "" {
+			key = grantType
+		}
+		if name, ok := op.actions[key]; ok {
+			return name
+		}
+		break
+	}
+
+	path := endpoint
+	if u, err := url.Parse(endpoint); err == nil {
+		path = u.Path
+	}
+	return "withings " + path + " " + action
+}
@@ -0,0 +1,238 @@
+package withingsotel_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jrmycanady/withings"
+	"github.com/jrmycanady/withings/withingsotel"
+	"github.com/jrmycanady/withings/withingstest"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+)
+
+// newTracedClient returns a client of the server tracing to an in memory exporter.
+func newTracedClient(srv *withingstest.Server, opts ...withings.ClientOption) (*withings.Client, *tracetest.InMemoryExporter, *sdktrace.TracerProvider) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	opts = append([]withings.ClientOption{
+		withings.WithMiddleware(withingsotel.Middleware(withingsotel.WithTracerProvider(provider))),
+	}, opts...)
+	return srv.NewClient(url.URL{}, opts...), exporter, provider
+}
+
+// spanNames returns the names of the spans.
+func spanNames(spans tracetest.SpanStubs) []string {
+	names := make([]string, 0, len(spans))
+	for _, span := range spans {
+		names = append(names, span.Name)
+	}
+	return names
+}
+
+// attributes returns the attributes of the span as a map.
+func attributes(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	srv := withingstest.NewServer(withingstest.WithPageSize(3))
+	defer srv.Close()
+	srv.AddUser(withingstest.NewDemoUser("1", time.Now()))
+	token := srv.IssueToken("1")
+
+	t.Run("Starts a span per action", func(t *testing.T) {
+		c, exporter, _ := newTracedClient(srv)
+		ctx := context.Background()
+
+		if _, err := c.GetMeasure(ctx, token, withings.GetMeasureParam{}); err != nil {
+			t.Fatalf("GetMeasure failed: %s", err)
+		}
+		if _, err := c.GetSleepSummary(ctx, token, withings.GetSleepSummaryParam{}); err != nil {
+			t.Fatalf("GetSleepSummary failed: %s", err)
+		}
+		_, err := c.SubscribeToNotifications(ctx, token, withings.SubscribeToNotificationsParam{
+			CallbackURL: url.URL{Scheme: "https", Host: "example.com", Path: "/notify"},
+			Appli:       1,
+		})
+		if err != nil {
+			t.Fatalf("SubscribeToNotifications failed: %s", err)
+		}
+		refreshed, err := c.RefreshAccessTokenContext(ctx, token)
+		if err != nil {
+			t.Fatalf("RefreshAccessTokenContext failed: %s", err)
+		}
+		token = refreshed.AccessToken
+
+		spans := exporter.GetSpans()
+		want := []string{"GetMeasure", "GetSleepSummary", "SubscribeToNotifications", "RefreshAccessToken"}
+		if names := spanNames(spans); !reflect.DeepEqual(names, want) {
+			t.Fatalf("got spans %v, want %v", names, want)
+		}
+		for _, span := range spans {
+			if span.Status.Code != codes.Unset {
+				t.Errorf("span %s has status %s, want %s", span.Name, span.Status.Code, codes.Unset)
+			}
+		}
+
+		attrs := attributes(spans[0])
+		if got, want := attrs[withingsotel.AttributeEndpoint].AsString(), srv.URL+withings.PathMeasure; got != want {
+			t.Errorf("got endpoint %s, want %s", got, want)
+		}
+		if got := attrs[withingsotel.AttributeAction].AsString(); got != withings.APIActionGetMeasure {
+			t.Errorf("got action %s, want %s", got, withings.APIActionGetMeasure)
+		}
+		if got := attrs[withingsotel.AttributeStatus].AsInt64(); got != withings.StatusOK {
+			t.Errorf("got status %d, want %d", got, withings.StatusOK)
+		}
+		if got := attrs[semconv.HTTPStatusCodeKey].AsInt64(); got != 200 {
+			t.Errorf("got http status %d, want 200", got)
+		}
+		if got := attrs[withingsotel.AttributeAttempt].AsInt64(); got != 1 {
+			t.Errorf("got attempt %d, want 1", got)
+		}
+
+		if got := attributes(spans[3])[withingsotel.AttributeAction].AsString(); got != "requesttoken" {
+			t.Errorf("got action %s, want requesttoken", got)
+		}
+	})
+
+	t.Run("Propagates the context", func(t *testing.T) {
+		c, exporter, provider := newTracedClient(srv)
+		ctx, parent := provider.Tracer("test").Start(context.Background(), "parent")
+
+		if _, err := c.GetAllActivities(ctx, token, withings.GetActivityParam{}); err != nil {
+			t.Fatalf("GetAllActivities failed: %s", err)
+		}
+		parent.End()
+
+		spans := exporter.GetSpans()
+		if len(spans) != 4 {
+			t.Fatalf("got %d spans, want 4", len(spans))
+		}
+		for i, span := range spans[:3] {
+			if span.Name != "GetActivity" {
+				t.Errorf("got span %s, want GetActivity", span.Name)
+			}
+			if span.Parent.SpanID() != parent.SpanContext().SpanID() {
+				t.Errorf("span %d is not a child of the parent span", i)
+			}
+			if span.SpanContext.TraceID() != parent.SpanContext().TraceID() {
+				t.Errorf("span %d is not in the trace of the parent span", i)
+			}
+
+			offset, ok := attributes(span)[withingsotel.AttributeOffset]
+			switch {
+			case i == 0 && ok:
+				t.Errorf("first page has offset %d, want none", offset.AsInt64())
+			case i > 0 && offset.AsInt64() != int64(i*3):
+				t.Errorf("page %d has offset %d, want %d", i, offset.AsInt64(), i*3)
+			}
+		}
+	})
+
+	t.Run("Records errors and attempts", func(t *testing.T) {
+		c, exporter, _ := newTracedClient(srv, withings.WithRetryPolicy(withings.RetryPolicy{
+			MaxAttempts:    3,
+			InitialBackoff: time.Millisecond,
+		}))
+		srv.Fail(withingstest.Failure{Path: withings.PathSleepV2, Status: withingstest.StatusTooManyRequests, Times: 3})
+
+		_, err := c.GetSleepSummary(context.Background(), token, withings.GetSleepSummaryParam{})
+		if !errors.Is(err, withings.ErrRateLimited) {
+			t.Fatalf("got error %v, want %v", err, withings.ErrRateLimited)
+		}
+
+		spans := exporter.GetSpans()
+		if len(spans) != 1 {
+			t.Fatalf("got %d spans, want 1", len(spans))
+		}
+		if spans[0].Status.Code != codes.Error {
+			t.Errorf("got status %s, want %s", spans[0].Status.Code, codes.Error)
+		}
+		if len(spans[0].Events) != 1 || spans[0].Events[0].Name != "exception" {
+			t.Errorf("got events %v, want a single exception", spans[0].Events)
+		}
+
+		attrs := attributes(spans[0])
+		if got := attrs[withingsotel.AttributeStatus].AsInt64(); got != withingstest.StatusTooManyRequests {
+			t.Errorf("got status %d, want %d", got, withingstest.StatusTooManyRequests)
+		}
+		if got := attrs[withingsotel.AttributeAttempt].AsInt64(); got != 3 {
+			t.Errorf("got attempt %d, want 3", got)
+		}
+	})
+}
+
+func TestSpanName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		endpoint string
+		params   url.Values
+		want     string
+	}{
+		{
+			name:     "Measure",
+			endpoint: withings.APIPathGetMeas,
+			params:   url.Values{"action": {withings.APIActionGetMeasure}},
+			want:     "GetMeasure",
+		},
+		{
+			name:     "Measure v2",
+			endpoint: withings.APIPathGetV2Measure,
+			params:   url.Values{"action": {withings.APIActionGetWorkout}},
+			want:     "GetWorkout",
+		},
+		{
+			name:     "Shared action",
+			endpoint: withings.APIHeartV2,
+			params:   url.Values{"action": {withings.APIActionGetHeartGet}},
+			want:     "GetHeartHighFrequencyData",
+		},
+		{
+			name:     "Access token",
+			endpoint: withings.APIPathUserAccessToken,
+			params:   url.Values{"action": {"requesttoken"}, "grant_type": {"authorization_code"}},
+			want:     "GetUserAccessToken",
+		},
+		{
+			name:     "Revoke",
+			endpoint: withings.APIPathUserAccessToken,
+			params:   url.Values{"action": {withings.APIActionRevoke}, "userid": {"1"}},
+			want:     "RevokeUser",
+		},
+		{
+			name:     "Unknown action",
+			endpoint: withings.APIPathGetMeas,
+			params:   url.Values{"action": {"unknown"}},
+			want:     "withings /measure unknown",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := withingsotel.SpanName(tt.endpoint, tt.params); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+// Package withingsprom exposes metrics of the API usage of a withings Client to Prometheus. It is a separate module so
+// the withings package does not depend on Prometheus unless metrics are collected.
+package withingsprom
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/jrmycanady/withings"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultNamespace is the namespace of the metrics unless the collector is configured with WithNamespace.
+const DefaultNamespace = "withings"
+
+// Outcomes of the requests counted by the collector.
+const (
+	// The API responded with status zero.
+	OutcomeSuccess = "success"
+
+	// The API responded with a non zero Withings status.
+	OutcomeAPIError = "api_error"
+
+	// The API responded with an HTTP status other than 200.
+	OutcomeHTTPError = "http_error"
+
+	// The request failed without a response, for example due to a network failure, a response that could not be
+	// parsed or the context ending.
+	OutcomeError = "error"
+)
+
+// Collector is a prometheus.Collector of the API usage of the clients its middleware is added to. A collector may be
+// shared by several clients.
+type Collector struct {
+	requests        *prometheus.CounterVec
+	duration        *prometheus.HistogramVec
+	statuses        *prometheus.CounterVec
+	refreshes       prometheus.Counter
+	refreshFailures prometheus.Counter
+	rateLimitWait   prometheus.Histogram
+	pages           *prometheus.CounterVec
+}
+
+// config is the configuration of a Collector.
+type config struct {
+	namespace   string
+	constLabels prometheus.Labels
+}
+
+// Option is an option that can be applied to a Collector.
+type Option func(c *config)
+
+// WithNamespace configures the namespace the names of the metrics are prefixed with. The default is
+// DefaultNamespace.
+func WithNamespace(namespace string) Option {
+	return func(c *config) {
+		c.namespace = namespace
+	}
+}
+
+// WithConstLabels configures labels added to every metric, for example to distinguish the collectors of several
+// applications.
+func WithConstLabels(labels prometheus.Labels) Option {
+	return func(c *config) {
+		c.constLabels = labels
+	}
+}
+
+// NewCollector returns a collector with every metric at zero. The collector must be registered with a
+// prometheus.Registerer and its middleware added to the clients to collect from.
+func NewCollector(opts ...Option) *Collector {
+	cfg := config{
+		namespace: DefaultNamespace,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return &Collector{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   cfg.namespace,
+			Name:        "requests_total",
+			Help:        "Number of API calls by service, action and outcome. Retries of a call are not counted.",
+			ConstLabels: cfg.constLabels,
+		}, []string{"service", "action", "outcome"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   cfg.namespace,
+			Name:        "request_duration_seconds",
+			Help:        "Duration of API calls by service and action, including every attempt and wait.",
+			ConstLabels: cfg.constLabels,
+			Buckets:     prometheus.DefBuckets,
+		}, []string{"service", "action"}),
+		statuses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   cfg.namespace,
+			Name:        "responses_total",
+			Help:        "Number of API responses by service, action and Withings status.",
+			ConstLabels: cfg.constLabels,
+		}, []string{"service", "action", "status"}),
+		refreshes: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   cfg.namespace,
+			Name:        "token_refreshes_total",
+			Help:        "Number of access token refreshes attempted.",
+			ConstLabels: cfg.constLabels,
+		}),
+		refreshFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   cfg.namespace,
+			Name:        "token_refresh_failures_total",
+			Help:        "Number of access token refreshes that failed.",
+			ConstLabels: cfg.constLabels,
+		}),
+		rateLimitWait: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace:   cfg.namespace,
+			Name:        "rate_limit_wait_seconds",
+			Help:        "Time API calls waited on the client side rate limiter.",
+			ConstLabels: cfg.constLabels,
+			Buckets:     []float64{0, .01, .05, .1, .5, 1, 5, 10, 30, 60},
+		}),
+		pages: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   cfg.namespace,
+			Name:        "pages_fetched_total",
+			Help:        "Number of pages fetched from paged services by service and action.",
+			ConstLabels: cfg.constLabels,
+		}, []string{"service", "action"}),
+	}
+}
+
+// Describe sends the descriptors of every metric of the collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.requests.Describe(ch)
+	c.duration.Describe(ch)
+	c.statuses.Describe(ch)
+	c.refreshes.Describe(ch)
+	c.refreshFailures.Describe(ch)
+	c.rateLimitWait.Describe(ch)
+	c.pages.Describe(ch)
+}
+
+// Collect sends every metric of the collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.requests.Collect(ch)
+	c.duration.Collect(ch)
+	c.statuses.Collect(ch)
+	c.refreshes.Collect(ch)
+	c.refreshFailures.Collect(ch)
+	c.rateLimitWait.Collect(ch)
+	c.pages.Collect(ch)
+}
+
+// Middleware returns the middleware collecting the metrics of every API call of a client. It should be added with
+// withings.WithMiddleware so each call is counted once no matter how many attempts it takes.
+func (c *Collector) Middleware() withings.Middleware {
+	return func(next withings.RoundTripFunc) withings.RoundTripFunc {
+		return func(call *withings.Call) (*withings.CallResponse, error) {
+			start := time.Now()
+
+			resp, err := next(call)
+
+			service := serviceOf(call.Endpoint)
+			c.requests.WithLabelValues(service, call.Action, outcomeOf(resp, err)).Inc()
+			c.duration.WithLabelValues(service, call.Action).Observe(time.Since(start).Seconds())
+			c.rateLimitWait.Observe(call.RateLimitWait.Seconds())
+
+			if resp != nil && resp.HTTPStatus == http.StatusOK {
+				c.statuses.WithLabelValues(service, call.Action, strconv.FormatInt(resp.Status, 10)).Inc()
+				if resp.Status == withings.StatusOK && isPage(resp.Body) {
+					c.pages.WithLabelValues(service, call.Action).Inc()
+				}
+			}
+
+			if call.Params().Get("grant_type") == "refresh_token" {
+				c.refreshes.Inc()
+				if err != nil {
+					c.refreshFailures.Inc()
+				}
+			}
+
+			return resp, err
+		}
+	}
+}
+
+// serviceOf returns the path of the service of the endpoint.
+func serviceOf(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return endpoint
+	}
+	return u.Path
+}
+
+// outcomeOf returns the outcome of a call that resulted in resp and err.
+func outcomeOf(resp *withings.CallResponse, err error) string {
+	switch {
+	case resp == nil:
+		return OutcomeError
+	case resp.HTTPStatus != http.StatusOK:
+		return OutcomeHTTPError
+	case resp.Status != withings.StatusOK:
+		return OutcomeAPIError
+	case err != nil:
+		return OutcomeError
+	}
+	return OutcomeSuccess
+}
+
+// isPage reports whether the body is a page of a paged service, which is identified by the more value in the body.
+func isPage(body []byte) bool {
+	var page struct {
+		Body struct {
+			More json.RawMessage `json:"more"`
+		} `json:"body"`
+	}
+	if err := json.Unmarshal(body, &page); err != nil {
+		return false
+	}
+	return page.Body.More != nil
+}
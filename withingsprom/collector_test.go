@@ -0,0 +1,120 @@
+package withingsprom_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jrmycanady/withings"
+	"github.com/jrmycanady/withings/withingsprom"
+	"github.com/jrmycanady/withings/withingstest"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gather returns the metrics of the registry with the name provided, keyed by their labels in the form
+// name=value,name=value.
+func gather(t *testing.T, reg *prometheus.Registry, name string) map[string]*dto.Metric {
+	t.Helper()
+
+	families, err := reg.Gather()
+	require.Nil(t, err)
+
+	metrics := make(map[string]*dto.Metric)
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			key := ""
+			for i, l := range m.GetLabel() {
+				if i > 0 {
+					key += ","
+				}
+				key += l.GetName() + "=" + l.GetValue()
+			}
+			metrics[key] = m
+		}
+	}
+	return metrics
+}
+
+func TestCollector(t *testing.T) {
+	t.Parallel()
+
+	srv := withingstest.NewServer(withingstest.WithPageSize(3))
+	defer srv.Close()
+	srv.AddUser(withingstest.NewDemoUser("1", time.Now()))
+	ctx := context.Background()
+
+	collector := withingsprom.NewCollector()
+	reg := prometheus.NewRegistry()
+	require.Nil(t, reg.Register(collector))
+
+	c := srv.NewClient(url.URL{},
+		withings.WithMiddleware(collector.Middleware()),
+		withings.WithRateLimit(1, 20*time.Millisecond, 1),
+	)
+	token := srv.IssueToken("1")
+
+	activities, err := c.GetAllActivities(ctx, token, withings.GetActivityParam{})
+	require.Nil(t, err)
+	require.Len(t, activities, 7)
+
+	srv.Fail(withingstest.Failure{Path: withings.PathMeasure, Status: withingstest.StatusTooManyRequests})
+	_, err = c.GetMeasure(ctx, token, withings.GetMeasureParam{})
+	require.ErrorIs(t, err, withings.ErrRateLimited)
+
+	_, err = c.RefreshAccessTokenContext(ctx, token)
+	require.Nil(t, err)
+	_, err = c.RefreshAccessTokenContext(ctx, token)
+	require.NotNil(t, err)
+
+	requests := gather(t, reg, "withings_requests_total")
+	assert.Equal(t, 3.0, requests["action=getactivity,outcome=success,service=/v2/measure"].GetCounter().GetValue())
+	assert.Equal(t, 1.0, requests["action=getmeas,outcome=api_error,service=/measure"].GetCounter().GetValue())
+	assert.Equal(t, 1.0, requests["action=requesttoken,outcome=success,service=/v2/oauth2"].GetCounter().GetValue())
+
+	statuses := gather(t, reg, "withings_responses_total")
+	assert.Equal(t, 3.0, statuses["action=getactivity,service=/v2/measure,status=0"].GetCounter().GetValue())
+	assert.Equal(t, 1.0, statuses["action=getmeas,service=/measure,status=601"].GetCounter().GetValue())
+
+	pages := gather(t, reg, "withings_pages_fetched_total")
+	assert.Equal(t, 3.0, pages["action=getactivity,service=/v2/measure"].GetCounter().GetValue())
+	assert.Nil(t, pages["action=requesttoken,service=/v2/oauth2"])
+
+	assert.Equal(t, 2.0, gather(t, reg, "withings_token_refreshes_total")[""].GetCounter().GetValue())
+	assert.Equal(t, 1.0, gather(t, reg, "withings_token_refresh_failures_total")[""].GetCounter().GetValue())
+
+	duration := gather(t, reg, "withings_request_duration_seconds")["action=getactivity,service=/v2/measure"]
+	assert.Equal(t, uint64(3), duration.GetHistogram().GetSampleCount())
+
+	wait := gather(t, reg, "withings_rate_limit_wait_seconds")[""]
+	assert.Equal(t, uint64(6), wait.GetHistogram().GetSampleCount())
+	assert.Greater(t, wait.GetHistogram().GetSampleSum(), 0.05)
+}
+
+func TestCollector_WithNamespace(t *testing.T) {
+	t.Parallel()
+
+	srv := withingstest.NewServer()
+	defer srv.Close()
+	srv.AddUser(withingstest.NewDemoUser("1", time.Now()))
+
+	collector := withingsprom.NewCollector(
+		withingsprom.WithNamespace("app"),
+		withingsprom.WithConstLabels(prometheus.Labels{"client": "sync"}),
+	)
+	reg := prometheus.NewRegistry()
+	require.Nil(t, reg.Register(collector))
+
+	c := srv.NewClient(url.URL{}, withings.WithMiddleware(collector.Middleware()))
+	_, err := c.GetMeasure(context.Background(), srv.IssueToken("1"), withings.GetMeasureParam{})
+	require.Nil(t, err)
+
+	requests := gather(t, reg, "app_requests_total")
+	assert.Equal(t, 1.0, requests["action=getmeas,client=sync,outcome=success,service=/measure"].GetCounter().GetValue())
+}
@@ -0,0 +1,337 @@
+package withingstest
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jrmycanady/withings"
+)
+
+// handleMeasure serves the measure service.
+func (s *Server) handleMeasure(w http.ResponseWriter, params url.Values, u *User) {
+	switch params.Get("action") {
+	case withings.APIActionGetMeasure:
+		types := make(map[withings.MeasureType]bool)
+		for _, v := range strings.Split(params.Get("meastypes"), ",") {
+			if t, err := strconv.ParseInt(v, 10, 64); err == nil {
+				types[withings.MeasureType(t)] = true
+			}
+		}
+
+		groups := make(withings.MeasureGroups, 0, len(u.MeasureGroups))
+		for _, g := range u.MeasureGroups {
+			if !inWindow(params, g.Date, g.Created) {
+				continue
+			}
+			if len(types) > 0 {
+				measures := make(withings.Measures, 0, len(g.Measures))
+				for _, m := range g.Measures {
+					if types[m.Type] {
+						measures = append(measures, m)
+					}
+				}
+				if len(measures) == 0 {
+					continue
+				}
+				g.Measures = measures
+			}
+			groups = append(groups, g)
+		}
+
+		start, end, more, offset := s.page(params, len(groups))
+		writeBody(w, withings.GetMeasureBody{
+			UpdateTime:    s.now().Unix(),
+			Timezone:      "Europe/Paris",
+			MeasureGroups: groups[start:end],
+			More:          boolToInt(more),
+			Offset:        offset,
+		})
+	default:
+		writeError(w, StatusWrongAction, "Wrong action or wrong webservice")
+	}
+}
+
+// handleMeasureV2 serves the measure v2 service.
+func (s *Server) handleMeasureV2(w http.ResponseWriter, params url.Values, u *User) {
+	switch params.Get("action") {
+	case withings.APIActionGetActivity:
+		start, end, more, offset := s.page(params, len(u.Activities))
+		writeBody(w, withings.GetActivityBody{
+			Activities: u.Activities[start:end],
+			More:       more,
+			Offset:     offset,
+		})
+	case withings.APIActionGetIntraDayActivity:
+		capWindow(params, withings.MaxIntraDayActivityWindow)
+		series := make(withings.IntraDayActivities)
+		for ts, a := range u.IntraDayActivities {
+			if inWindow(params, ts, ts) {
+				series[ts] = a
+			}
+		}
+		writeBody(w, withings.GetIntraDayActivityBody{
+			Series: series,
+		})
+	case withings.APIActionGetWorkout:
+		workouts := make(withings.Workouts, 0, len(u.Workouts))
+		for _, wo := range u.Workouts {
+			if inWindow(params, int64(wo.StartDate), int64(wo.Modified)) {
+				workouts = append(workouts, wo)
+			}
+		}
+
+		start, end, more, offset := s.page(params, len(workouts))
+		writeBody(w, withings.GetWorkoutBody{
+			Series: workouts[start:end],
+			More:   more,
+			Offset: offset,
+		})
+	default:
+		writeError(w, StatusWrongAction, "Wrong action or wrong webservice")
+	}
+}
+
+// handleHeartV2 serves the heart v2 service.
+func (s *Server) handleHeartV2(w http.ResponseWriter, params url.Values, u *User) {
+	switch params.Get("action") {
+	case withings.APIActionGetHeartList:
+		series := make(withings.HeartDatas, 0, len(u.HeartData))
+		for _, h := range u.HeartData {
+			if inWindow(params, h.Timestamp, h.Timestamp) {
+				series = append(series, h)
+			}
+		}
+
+		start, end, more, offset := s.page(params, len(series))
+		writeBody(w, withings.GetHeartBody{
+			Series: series[start:end],
+			More:   more,
+			Offset: offset,
+		})
+	case withings.APIActionGetHeartGet:
+		signalID, _ := strconv.ParseInt(params.Get("signalid"), 10, 64)
+		signal, ok := u.HeartSignals[signalID]
+		if !ok {
+			writeError(w, StatusInvalidParams, "Invalid Params: unknown signalid")
+			return
+		}
+		writeBody(w, signal)
+	default:
+		writeError(w, StatusWrongAction, "Wrong action or wrong webservice")
+	}
+}
+
+// handleStethoV2 serves the stetho v2 service.
+func (s *Server) handleStethoV2(w http.ResponseWriter, params url.Values, u *User) {
+	switch params.Get("action") {
+	case withings.APIActionGetStethoList:
+		series := make(withings.StethoRecordings, 0, len(u.StethoRecordings))
+		for _, r := range u.StethoRecordings {
+			if inWindow(params, r.Timestamp, r.Timestamp) {
+				series = append(series, r)
+			}
+		}
+
+		start, end, more, offset := s.page(params, len(series))
+		writeBody(w, withings.GetStethoListBody{
+			Series: series[start:end],
+			More:   more,
+			Offset: offset,
+		})
+	case withings.APIActionGetStethoSignal:
+		signalID, _ := strconv.ParseInt(params.Get("signalid"), 10, 64)
+		signal, ok := u.StethoSignals[signalID]
+		if !ok {
+			writeError(w, StatusInvalidParams, "Invalid Params: unknown signalid")
+			return
+		}
+		writeBody(w, signal)
+	default:
+		writeError(w, StatusWrongAction, "Wrong action or wrong webservice")
+	}
+}
+
+// handleSleepV2 serves the sleep v2 service.
+func (s *Server) handleSleepV2(w http.ResponseWriter, params url.Values, u *User) {
+	switch params.Get("action") {
+	case withings.APIActionGetSleep:
+		capWindow(params, withings.MaxSleepWindow)
+		series := make(withings.Sleeps, 0, len(u.Sleeps))
+		for _, sl := range u.Sleeps {
+			if inWindow(params, int64(sl.StartDate), int64(sl.StartDate)) {
+				series = append(series, sl)
+			}
+		}
+		writeBody(w, withings.GetSleepBody{
+			Series: series,
+		})
+	case withings.APIActionGetSleepSummary:
+		series := make(withings.SleepSummaries, 0, len(u.SleepSummaries))
+		for _, sl := range u.SleepSummaries {
+			if inWindow(params, int64(sl.StartDate), int64(sl.Modified)) {
+				series = append(series, sl)
+			}
+		}
+
+		start, end, more, offset := s.page(params, len(series))
+		writeBody(w, withings.GetSleepSummaryBody{
+			Series: series[start:end],
+			More:   more,
+			Offset: offset,
+		})
+	default:
+		writeError(w, StatusWrongAction, "Wrong action or wrong webservice")
+	}
+}
+
+// handleNotify serves the notify service.
+func (s *Server) handleNotify(w http.ResponseWriter, params url.Values, u *User) {
+	appli, _ := strconv.Atoi(params.Get("appli"))
+	callbackURL := params.Get("callbackurl")
+
+	// Locates the subscription matching the callback URL and, if provided, the appli.
+	find := func() int {
+		for i, n := range u.Notifications {
+			if n.Callbackurl == callbackURL && (params.Get("appli") == "" || n.Appli == appli) {
+				return i
+			}
+		}
+		return -1
+	}
+
+	switch params.Get("action") {
+	case withings.APIActionNotificationSubscribe:
+		if params.Get("client_id") != s.clientID {
+			writeError(w, StatusInvalidParams, "Invalid Params: invalid client_id")
+			return
+		}
+		if i := find(); i >= 0 {
+			u.Notifications = append(u.Notifications[:i], u.Notifications[i+1:]...)
+		}
+		u.Notifications = append(u.Notifications, withings.Notification{
+			Appli:       appli,
+			Callbackurl: callbackURL,
+			Comment:     params.Get("comment"),
+		})
+		writeBody(w, struct{}{})
+	case withings.APIActionNotificationGet:
+		i := find()
+		if i < 0 {
+			writeError(w, StatusInvalidParams, "Invalid Params: no such subscription")
+			return
+		}
+		writeBody(w, u.Notifications[i])
+	case withings.APIActionNotificationList:
+		profiles := make(withings.Notifications, 0, len(u.Notifications))
+		for _, n := range u.Notifications {
+			if params.Get("appli") == "" || n.Appli == appli {
+				profiles = append(profiles, n)
+			}
+		}
+		writeBody(w, withings.ListNotificationBody{
+			Profiles: profiles,
+		})
+	case withings.APIActionNotificationRevoke:
+		i := find()
+		if i < 0 {
+			writeError(w, StatusInvalidParams, "Invalid Params: no such subscription")
+			return
+		}
+		u.Notifications = append(u.Notifications[:i], u.Notifications[i+1:]...)
+		writeBody(w, struct{}{})
+	case withings.APIActionNotificationUpdate:
+		i := find()
+		if i < 0 {
+			writeError(w, StatusInvalidParams, "Invalid Params: no such subscription")
+			return
+		}
+		if v := params.Get("new_callbackurl"); v != "" {
+			u.Notifications[i].Callbackurl = v
+		}
+		if v, err := strconv.Atoi(params.Get("new_appli")); err == nil {
+			u.Notifications[i].Appli = v
+		}
+		if _, ok := params["comment"]; ok {
+			u.Notifications[i].Comment = params.Get("comment")
+		}
+		writeBody(w, struct{}{})
+	default:
+		writeError(w, StatusWrongAction, "Wrong action or wrong webservice")
+	}
+}
+
+// handleUserV2 serves the user v2 service.
+func (s *Server) handleUserV2(w http.ResponseWriter, params url.Values, u *User) {
+	switch params.Get("action") {
+	case withings.APIActionUserGetDevice:
+		writeBody(w, withings.GetUserDeviceBody{
+			Devices: u.Devices,
+		})
+	case withings.APIActionUserGetGoals:
+		writeBody(w, withings.GetUserGoalsBody{
+			Goals: u.Goals,
+		})
+	default:
+		writeError(w, StatusWrongAction, "Wrong action or wrong webservice")
+	}
+}
+
+// page determines the window of n entries to return based on the offset parameter and the page size. It returns the
+// bounds of the window along with the more and offset values of the response.
+func (s *Server) page(params url.Values, n int) (start int, end int, more bool, offset int64) {
+	offset, _ = strconv.ParseInt(params.Get("offset"), 10, 64)
+	start = int(offset)
+	if start > n || start < 0 {
+		start = n
+	}
+
+	end = start + s.pageSize
+	if end >= n {
+		return start, n, false, 0
+	}
+
+	return start, end, true, int64(end)
+}
+
+// inWindow reports whether an entry falls within the startdate, enddate and lastupdate parameters provided. The date
+// is compared against startdate and enddate, modified is compared against lastupdate. When lastupdate is provided
+// startdate and enddate are ignored as the real API does.
+func inWindow(params url.Values, date int64, modified int64) bool {
+	if v, err := strconv.ParseInt(params.Get("lastupdate"), 10, 64); err == nil {
+		return modified >= v
+	}
+	if v, err := strconv.ParseInt(params.Get("startdate"), 10, 64); err == nil && date < v {
+		return false
+	}
+	if v, err := strconv.ParseInt(params.Get("enddate"), 10, 64); err == nil && date > v {
+		return false
+	}
+	return true
+}
+
+// capWindow shortens the window of the request to max like the API silently does for services limited to a
+// maximum range.
+func capWindow(params url.Values, max time.Duration) {
+	start, err := strconv.ParseInt(params.Get("startdate"), 10, 64)
+	if err != nil {
+		return
+	}
+	end, err := strconv.ParseInt(params.Get("enddate"), 10, 64)
+	if err != nil {
+		return
+	}
+	if limit := start + int64(max/time.Second); end > limit {
+		params.Set("enddate", strconv.FormatInt(limit, 10))
+	}
+}
+
+// boolToInt converts v to the 0 or 1 form some services use for more.
+func boolToInt(v bool) int64 {
+	if v {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,171 @@
+package withingstest
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/jrmycanady/withings"
+)
+
+// authorizePage is the page served for the authorization request. It mirrors the form of the real page closely
+// enough for the CSRF token to be extracted and the form submitted.
+const authorizePage = `<html>
+<body>
+<form method="post">
+<input type="hidden" name="csrf_token" value="%s">
+<button type="submit" name="authorized" value="1">Allow this app</button>
+</form>
+</body>
+</html>
+`
+
+// handleAuthorize serves the account authorization page. A GET renders the page and a POST with authorized set
+// grants access for the authorizing user and redirects to the redirect_uri with the code and state.
+func (s *Server) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	params, failure := s.record(r)
+	if failure != nil {
+		writeFailure(w, failure)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPost:
+		if !s.csrf[params.Get("csrf_token")] {
+			http.Error(w, "invalid csrf token", http.StatusBadRequest)
+			return
+		}
+		delete(s.csrf, params.Get("csrf_token"))
+
+		redirectURL, err := url.Parse(params.Get("redirect_uri"))
+		if err != nil {
+			http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+			return
+		}
+
+		q := redirectURL.Query()
+		switch {
+		case params.Get("authorized") != "1":
+			q.Set("error", "access_denied")
+		case params.Get("client_id") != s.clientID:
+			q.Set("error", "invalid_client")
+		case s.users[s.authorizingUser] == nil:
+			q.Set("error", "server_error")
+		default:
+			code := randomString()
+			s.codes[code] = issuedCode{
+				userID:      s.authorizingUser,
+				redirectURI: params.Get("redirect_uri"),
+				scope:       params.Get("scope"),
+			}
+			q.Set("code", code)
+		}
+		q.Set("state", params.Get("state"))
+		redirectURL.RawQuery = q.Encode()
+
+		http.Redirect(w, r, redirectURL.String(), http.StatusFound)
+	default:
+		csrf := randomString()
+		s.csrf[csrf] = true
+		fmt.Fprintf(w, authorizePage, html.EscapeString(csrf))
+	}
+}
+
+// handleOAuth2 serves the oauth2 service.
+func (s *Server) handleOAuth2(w http.ResponseWriter, r *http.Request) {
+	params, failure := s.record(r)
+	if failure != nil {
+		writeFailure(w, failure)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch params.Get("action") {
+	case "requesttoken":
+	case withings.APIActionRevoke:
+		s.handleRevokeLocked(w, params)
+		return
+	default:
+		writeError(w, StatusWrongAction, "Wrong action or wrong webservice")
+		return
+	}
+
+	if params.Get("client_id") != s.clientID || params.Get("client_secret") != s.clientSecret {
+		writeError(w, StatusInvalidParams, "Invalid Params: invalid client_id or client_secret")
+		return
+	}
+
+	var t *issuedToken
+	switch params.Get("grant_type") {
+	case "authorization_code":
+		code, ok := s.codes[params.Get("code")]
+		if !ok {
+			writeError(w, StatusInvalidParams, "Invalid Params: invalid code")
+			return
+		}
+		if code.redirectURI != params.Get("redirect_uri") {
+			writeError(w, StatusInvalidParams, "Invalid Params: invalid redirect_uri")
+			return
+		}
+		delete(s.codes, params.Get("code"))
+		t = s.issueTokenLocked(code.userID, code.scope)
+	case "refresh_token":
+		old, ok := s.refreshTokens[params.Get("refresh_token")]
+		if !ok {
+			writeError(w, StatusInvalidParams, "Invalid Params: invalid refresh_token")
+			return
+		}
+
+		// Refresh tokens are single use. The old access token stays valid until it expires.
+		delete(s.refreshTokens, old.refreshToken)
+		t = s.issueTokenLocked(old.userID, old.scope)
+	default:
+		writeError(w, StatusInvalidParams, "Invalid Params: invalid grant_type")
+		return
+	}
+
+	writeBody(w, map[string]interface{}{
+		"userid":        t.userID,
+		"access_token":  t.accessToken,
+		"refresh_token": t.refreshToken,
+		"scope":         t.scope,
+		"expires_in":    int64(s.tokenLifetime / time.Second),
+		"csrf_token":    randomString(),
+		"token_type":    "Bearer",
+	})
+}
+
+// handleRevokeLocked serves the signed revoke action of the oauth2 service, invalidating every token issued for the
+// user. The caller must hold the lock.
+func (s *Server) handleRevokeLocked(w http.ResponseWriter, params url.Values) {
+	if !s.useNonceLocked(params) {
+		writeError(w, StatusInvalidParams, "Invalid Params: invalid nonce or signature")
+		return
+	}
+
+	userID := params.Get("userid")
+	if s.users[userID] == nil {
+		writeError(w, StatusInvalidParams, "Invalid Params: unknown userid")
+		return
+	}
+
+	for accessToken, t := range s.accessTokens {
+		if t.userID == userID {
+			delete(s.accessTokens, accessToken)
+		}
+	}
+	for refreshToken, t := range s.refreshTokens {
+		if t.userID == userID {
+			delete(s.refreshTokens, refreshToken)
+		}
+	}
+
+	writeBody(w, struct{}{})
+}
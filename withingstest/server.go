@@ -0,0 +1,464 @@
+// Package withingstest provides an in-process fake of the Withings API for use in tests. The fake implements the
+// services and actions the withings Client calls against seedable per user datasets, issues and expires OAuth 2.0
+// tokens, pages results with more/offset and can be scripted to return API error statuses.
+package withingstest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jrmycanady/withings"
+)
+
+// Withings statuses returned by the fake server.
+const (
+	StatusOK               = withings.StatusOK
+	StatusInvalidToken     = withings.StatusAuthenticationFailed
+	StatusInvalidParams    = withings.StatusInvalidParams
+	StatusTooManyRequests  = withings.StatusTooManyRequests
+	StatusWrongAction      = withings.StatusWrongAction
+	StatusUnknownError     = withings.StatusUnknownError
+	StatusServiceUndefined = withings.StatusServiceNotDefined
+)
+
+const (
+	// DefaultClientID is the client ID the server accepts unless configured with WithClientCredentials.
+	DefaultClientID = "withingstest-client-id"
+
+	// DefaultClientSecret is the client secret the server accepts unless configured with WithClientCredentials.
+	DefaultClientSecret = "withingstest-client-secret"
+
+	// DefaultPageSize is the number of entries returned per page unless configured with WithPageSize.
+	DefaultPageSize = 100
+
+	// DefaultTokenLifetime is the lifetime of issued access tokens unless configured with WithTokenLifetime.
+	DefaultTokenLifetime = 3 * time.Hour
+)
+
+// Server is a fake Withings API server. It serves both the API and account services from the same listener so a
+// client only needs to be pointed at URL.
+type Server struct {
+	*httptest.Server
+
+	// Contains the client credentials the server accepts on token requests.
+	clientID     string
+	clientSecret string
+
+	// Denotes the maximum number of entries returned in a single page.
+	pageSize int
+
+	// Denotes how long issued access tokens are valid for.
+	tokenLifetime time.Duration
+
+	// Provides the current time. It is replaceable so tests can control token expiry.
+	now func() time.Time
+
+	mu sync.Mutex
+
+	// Contains the seeded users keyed by their user ID.
+	users map[string]*User
+
+	// Contains the user that grants access when the authorization page is submitted.
+	authorizingUser string
+
+	// Contains the issued tokens keyed by access token and refresh token.
+	accessTokens  map[string]*issuedToken
+	refreshTokens map[string]*issuedToken
+
+	// Contains the issued authorization codes and CSRF tokens.
+	codes map[string]issuedCode
+	csrf  map[string]bool
+
+	// Contains the issued nonces that have not been used by a signed request yet.
+	nonces map[string]bool
+
+	// Contains the failures that will be returned in place of handling requests.
+	failures []*Failure
+
+	// Contains every request received in the order received.
+	requests []Request
+}
+
+// issuedToken is a token pair issued by the server.
+type issuedToken struct {
+	userID       string
+	accessToken  string
+	refreshToken string
+	scope        string
+	expiresAt    time.Time
+}
+
+// issuedCode is an authorization code issued by the server.
+type issuedCode struct {
+	userID      string
+	redirectURI string
+	scope       string
+}
+
+// Request is a record of a request received by the server.
+type Request struct {
+	// The path of the service that was called.
+	Path string
+
+	// The action that was requested.
+	Action string
+
+	// The combined query and form parameters of the request.
+	Params url.Values
+
+	// The bearer token provided in the Authorization header, if any.
+	AccessToken string
+}
+
+// Failure is a scripted failure returned by the server in place of handling a request.
+type Failure struct {
+	// The service path the failure applies to. If empty the failure applies to every service.
+	Path string
+
+	// The action the failure applies to. If empty the failure applies to every action.
+	Action string
+
+	// The Withings status returned in the response body.
+	Status int64
+
+	// The error text returned in the response body.
+	Error string
+
+	// The HTTP status code written. If zero http.StatusOK is used as the Withings API does.
+	HTTPStatus int
+
+	// The number of requests the failure applies to. If zero the failure applies to a single request.
+	Times int
+}
+
+// Option is an option that can be applied to a Server.
+type Option func(s *Server)
+
+// WithClientCredentials configures the client ID and secret the server accepts.
+func WithClientCredentials(clientID string, clientSecret string) Option {
+	return func(s *Server) {
+		s.clientID = clientID
+		s.clientSecret = clientSecret
+	}
+}
+
+// WithPageSize configures the maximum number of entries the server returns per page.
+func WithPageSize(size int) Option {
+	return func(s *Server) {
+		s.pageSize = size
+	}
+}
+
+// WithTokenLifetime configures how long access tokens issued by the server are valid for.
+func WithTokenLifetime(lifetime time.Duration) Option {
+	return func(s *Server) {
+		s.tokenLifetime = lifetime
+	}
+}
+
+// WithClock configures the function the server uses to determine the current time.
+func WithClock(now func() time.Time) Option {
+	return func(s *Server) {
+		s.now = now
+	}
+}
+
+// NewServer starts and returns a new fake Withings API server. The caller should call Close when finished.
+func NewServer(opts ...Option) *Server {
+	s := &Server{
+		clientID:      DefaultClientID,
+		clientSecret:  DefaultClientSecret,
+		pageSize:      DefaultPageSize,
+		tokenLifetime: DefaultTokenLifetime,
+		now:           time.Now,
+		users:         make(map[string]*User),
+		accessTokens:  make(map[string]*issuedToken),
+		refreshTokens: make(map[string]*issuedToken),
+		codes:         make(map[string]issuedCode),
+		csrf:          make(map[string]bool),
+		nonces:        make(map[string]bool),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(withings.PathUserAuthorize, s.handleAuthorize)
+	mux.HandleFunc(withings.PathOAuth2, s.handleOAuth2)
+	mux.HandleFunc(withings.PathMeasure, s.authorized(s.handleMeasure))
+	mux.HandleFunc(withings.PathMeasureV2, s.authorized(s.handleMeasureV2))
+	mux.HandleFunc(withings.PathHeartV2, s.authorized(s.handleHeartV2))
+	mux.HandleFunc(withings.PathSleepV2, s.authorized(s.handleSleepV2))
+	mux.HandleFunc(withings.PathStethoV2, s.authorized(s.handleStethoV2))
+	mux.HandleFunc(withings.PathNotify, s.authorized(s.handleNotify))
+	mux.HandleFunc(withings.PathUserV2, s.authorized(s.handleUserV2))
+	mux.HandleFunc(withings.PathSignatureV2, s.handleSignatureV2)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, StatusServiceUndefined, "Service is not defined")
+	})
+
+	s.Server = httptest.NewServer(mux)
+
+	return s
+}
+
+// BaseURL returns the URL of the server as a url.URL.
+func (s *Server) BaseURL() url.URL {
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		panic(err)
+	}
+	return *u
+}
+
+// ClientCredentials returns the client ID and secret the server accepts.
+func (s *Server) ClientCredentials() (clientID string, clientSecret string) {
+	return s.clientID, s.clientSecret
+}
+
+// ClientOptions returns the options that point a withings.Client at the server.
+func (s *Server) ClientOptions() []withings.ClientOption {
+	return []withings.ClientOption{
+		withings.WithAPIBaseURL(s.BaseURL()),
+		withings.WithAccountBaseURL(s.BaseURL()),
+	}
+}
+
+// NewClient creates a new withings.Client using the server credentials that is pointed at the server. Any options
+// provided are applied after those pointing the client at the server.
+func (s *Server) NewClient(redirectURL url.URL, opts ...withings.ClientOption) *withings.Client {
+	return withings.NewClient(s.clientID, s.clientSecret, redirectURL, append(s.ClientOptions(), opts...)...)
+}
+
+// AddUser seeds the server with the user provided. Any existing user with the same ID is replaced. The first user
+// added becomes the user that grants access through the authorization page.
+func (s *Server) AddUser(u *User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.users[u.ID] = u
+	if s.authorizingUser == "" {
+		s.authorizingUser = u.ID
+	}
+}
+
+// User returns the seeded user with the ID provided or nil if there is none.
+func (s *Server) User(userID string) *User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.users[userID]
+}
+
+// SetAuthorizingUser sets the user that grants access when the authorization page is submitted.
+func (s *Server) SetAuthorizingUser(userID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.authorizingUser = userID
+}
+
+// IssueToken issues a new token for the user without going through the authorization flow.
+func (s *Server) IssueToken(userID string) withings.AccessToken {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := s.issueTokenLocked(userID, "")
+
+	return withings.AccessToken{
+		UserID:       t.userID,
+		AccessToken:  t.accessToken,
+		RefreshToken: t.refreshToken,
+		ExpiresIn:    int64(s.tokenLifetime / time.Second),
+		ExpiresAt:    t.expiresAt,
+		TokenType:    "Bearer",
+	}
+}
+
+// IssueCode issues a new authorization code for the user that may be exchanged for a token.
+func (s *Server) IssueCode(userID string, redirectURI string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	code := randomString()
+	s.codes[code] = issuedCode{userID: userID, redirectURI: redirectURI}
+
+	return code
+}
+
+// ExpireToken immediately expires the access token provided.
+func (s *Server) ExpireToken(accessToken string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if t, ok := s.accessTokens[accessToken]; ok {
+		t.expiresAt = s.now().Add(-time.Second)
+	}
+}
+
+// Fail scripts a failure to be returned in place of handling the next matching requests. Failures are matched in
+// the order they were scripted.
+func (s *Server) Fail(f Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if f.Times <= 0 {
+		f.Times = 1
+	}
+	s.failures = append(s.failures, &f)
+}
+
+// Requests returns a copy of every request received by the server.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Request(nil), s.requests...)
+}
+
+// RequestCount returns the number of requests received for the service path and action provided. An empty action
+// counts every action of the service.
+func (s *Server) RequestCount(path string, action string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var count int
+	for _, r := range s.requests {
+		if r.Path == path && (action == "" || r.Action == action) {
+			count++
+		}
+	}
+	return count
+}
+
+// issueTokenLocked issues a new token pair for the user. The caller must hold s.mu.
+func (s *Server) issueTokenLocked(userID string, scope string) *issuedToken {
+	t := &issuedToken{
+		userID:       userID,
+		accessToken:  randomString(),
+		refreshToken: randomString(),
+		scope:        scope,
+		expiresAt:    s.now().Add(s.tokenLifetime),
+	}
+	s.accessTokens[t.accessToken] = t
+	s.refreshTokens[t.refreshToken] = t
+
+	return t
+}
+
+// record parses the request parameters, records the request and returns any scripted failure that applies to it.
+func (s *Server) record(r *http.Request) (url.Values, *Failure) {
+	if err := r.ParseForm(); err != nil {
+		return nil, &Failure{Status: StatusInvalidParams, Error: "Invalid Params: " + err.Error()}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	action := r.Form.Get("action")
+	s.requests = append(s.requests, Request{
+		Path:        r.URL.Path,
+		Action:      action,
+		Params:      r.Form,
+		AccessToken: strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "),
+	})
+
+	for i, f := range s.failures {
+		if (f.Path == "" || f.Path == r.URL.Path) && (f.Action == "" || f.Action == action) {
+			f.Times--
+			if f.Times == 0 {
+				s.failures = append(s.failures[:i], s.failures[i+1:]...)
+			}
+
+			// As with the Withings API the nonce of a failed request is used up.
+			delete(s.nonces, r.Form.Get("nonce"))
+			return r.Form, f
+		}
+	}
+
+	return r.Form, nil
+}
+
+// authorized wraps a service handler with request recording, scripted failures and bearer token validation. The
+// handler is called with the user the token was issued to.
+func (s *Server) authorized(handler func(w http.ResponseWriter, params url.Values, u *User)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		params, failure := s.record(r)
+		if failure != nil {
+			writeFailure(w, failure)
+			return
+		}
+
+		accessToken := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+		s.mu.Lock()
+		t, ok := s.accessTokens[accessToken]
+		var u *User
+		if ok && s.now().Before(t.expiresAt) {
+			u = s.users[t.userID]
+		}
+		s.mu.Unlock()
+
+		if u == nil {
+			writeError(w, StatusInvalidToken, "XRequestID: Not provided invalid_token: The access token provided is invalid")
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		handler(w, params, u)
+	}
+}
+
+// writeFailure writes the scripted failure as the response.
+func writeFailure(w http.ResponseWriter, f *Failure) {
+	if f.HTTPStatus != 0 && f.HTTPStatus != http.StatusOK {
+		w.WriteHeader(f.HTTPStatus)
+		fmt.Fprint(w, http.StatusText(f.HTTPStatus))
+		return
+	}
+	writeError(w, f.Status, f.Error)
+}
+
+// writeBody writes a successful response with the body provided.
+func writeBody(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Status int64       `json:"status"`
+		Body   interface{} `json:"body"`
+	}{
+		Status: StatusOK,
+		Body:   body,
+	})
+}
+
+// writeError writes an error response with the Withings status and error text provided.
+func writeError(w http.ResponseWriter, status int64, apiError string) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Status   int64    `json:"status"`
+		APIError string   `json:"error"`
+		Body     struct{} `json:"body"`
+	}{
+		Status:   status,
+		APIError: apiError,
+	})
+}
+
+// randomString returns a random hex string suitable for tokens and codes.
+func randomString() string {
+	v := make([]byte, 20)
+	if _, err := rand.Read(v); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(v)
+}
@@ -0,0 +1,112 @@
+package withingstest_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jrmycanady/withings"
+	"github.com/jrmycanady/withings/withingstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_Paging(t *testing.T) {
+	srv := withingstest.NewServer(withingstest.WithPageSize(3))
+	defer srv.Close()
+	srv.AddUser(withingstest.NewDemoUser("1", time.Now()))
+
+	c := srv.NewClient(url.URL{})
+	token := srv.IssueToken("1")
+
+	var offsets []int64
+	var total int
+	param := withings.GetActivityParam{}
+	for {
+		resp, err := c.GetActivity(context.Background(), token, param)
+		require.Nil(t, err)
+		total += len(resp.Body.Activities)
+		if !resp.Body.More {
+			break
+		}
+		offsets = append(offsets, resp.Body.Offset)
+		param.Offset = resp.Body.Offset
+	}
+
+	assert.Equal(t, 7, total)
+	assert.Equal(t, []int64{3, 6}, offsets)
+}
+
+func TestServer_TokenExpiry(t *testing.T) {
+	now := time.Now()
+	srv := withingstest.NewServer(withingstest.WithClock(func() time.Time { return now }))
+	defer srv.Close()
+	srv.AddUser(withingstest.NewDemoUser("1", now))
+
+	c := srv.NewClient(url.URL{})
+	token := srv.IssueToken("1")
+
+	_, err := c.GetUserDevice(context.Background(), token)
+	require.Nil(t, err)
+
+	now = now.Add(withingstest.DefaultTokenLifetime)
+	resp, err := c.GetUserDevice(context.Background(), token)
+	require.NotNil(t, err)
+	assert.Equal(t, withingstest.StatusInvalidToken, resp.Status)
+
+	refreshed, err := c.RefreshAccessToken(token)
+	require.Nil(t, err)
+	_, err = c.GetUserDevice(context.Background(), refreshed.AccessToken)
+	require.Nil(t, err)
+
+	// The refresh token is single use.
+	reused, err := c.RefreshAccessToken(token)
+	require.NotNil(t, err)
+	assert.Equal(t, withingstest.StatusInvalidParams, reused.Status)
+}
+
+func TestServer_AuthorizationCode(t *testing.T) {
+	srv := withingstest.NewServer()
+	defer srv.Close()
+	srv.AddUser(withingstest.NewDemoUser("1", time.Now()))
+
+	redirectURL, err := url.Parse(srv.URL + "/callback")
+	require.Nil(t, err)
+	c := srv.NewClient(*redirectURL)
+
+	code := srv.IssueCode("1", redirectURL.String())
+	resp, err := c.GetUserAccessToken(code)
+	require.Nil(t, err)
+
+	_, err = c.GetUserDevice(context.Background(), resp.AccessToken)
+	require.Nil(t, err)
+}
+
+func TestServer_Fail(t *testing.T) {
+	srv := withingstest.NewServer()
+	defer srv.Close()
+	srv.AddUser(withingstest.NewDemoUser("1", time.Now()))
+
+	c := srv.NewClient(url.URL{})
+	token := srv.IssueToken("1")
+
+	srv.Fail(withingstest.Failure{
+		Path:   withings.PathMeasure,
+		Action: withings.APIActionGetMeasure,
+		Status: withingstest.StatusTooManyRequests,
+		Error:  "Too Many Requests",
+		Times:  2,
+	})
+
+	for i := 0; i < 2; i++ {
+		resp, err := c.GetMeasure(context.Background(), token, withings.GetMeasureParam{})
+		require.NotNil(t, err)
+		assert.Equal(t, withingstest.StatusTooManyRequests, resp.Status)
+	}
+
+	resp, err := c.GetMeasure(context.Background(), token, withings.GetMeasureParam{})
+	require.Nil(t, err)
+	assert.Len(t, resp.Body.MeasureGroups, 7)
+	assert.Equal(t, 3, srv.RequestCount(withings.PathMeasure, withings.APIActionGetMeasure))
+}
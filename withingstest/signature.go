@@ -0,0 +1,69 @@
+package withingstest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/jrmycanady/withings"
+)
+
+// handleSignatureV2 serves the signature v2 service.
+func (s *Server) handleSignatureV2(w http.ResponseWriter, r *http.Request) {
+	params, failure := s.record(r)
+	if failure != nil {
+		writeFailure(w, failure)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch params.Get("action") {
+	case withings.APIActionGetNonce:
+		if _, err := strconv.ParseInt(params.Get("timestamp"), 10, 64); err != nil {
+			writeError(w, StatusInvalidParams, "Invalid Params: invalid timestamp")
+			return
+		}
+		if !s.validSignatureLocked(params, params.Get("timestamp")) {
+			writeError(w, StatusInvalidParams, "Invalid Params: invalid signature")
+			return
+		}
+
+		nonce := randomString()
+		s.nonces[nonce] = true
+		writeBody(w, withings.GetNonceBody{Nonce: nonce})
+	default:
+		writeError(w, StatusWrongAction, "Wrong action or wrong webservice")
+	}
+}
+
+// useNonceLocked reports whether the request has a valid signature over an unused nonce issued by the server. The
+// nonce is used up either way. The caller must hold the lock.
+func (s *Server) useNonceLocked(params url.Values) bool {
+	nonce := params.Get("nonce")
+	if !s.nonces[nonce] {
+		return false
+	}
+	delete(s.nonces, nonce)
+
+	return s.validSignatureLocked(params, nonce)
+}
+
+// validSignatureLocked reports whether the request is from the client the server accepts and is signed over its
+// action, the client ID and the value provided. The caller must hold the lock.
+func (s *Server) validSignatureLocked(params url.Values, value string) bool {
+	if params.Get("client_id") != s.clientID {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(s.clientSecret))
+	mac.Write([]byte(strings.Join([]string{params.Get("action"), s.clientID, value}, ",")))
+	want := hex.EncodeToString(mac.Sum(nil))
+
+	return hmac.Equal([]byte(want), []byte(params.Get("signature")))
+}
@@ -0,0 +1,190 @@
+package withingstest
+
+import (
+	"time"
+
+	"github.com/jrmycanady/withings"
+)
+
+// User is the dataset of a single Withings user served by the fake server. The slices are served in the order they
+// are stored so paging is stable.
+type User struct {
+	// The Withings user ID of the user.
+	ID string
+
+	// Data served by the measure service.
+	MeasureGroups withings.MeasureGroups
+
+	// Data served by the measure v2 service.
+	Activities         withings.Activities
+	IntraDayActivities withings.IntraDayActivities
+	Workouts           withings.Workouts
+
+	// Data served by the heart v2 service. HeartSignals is keyed by the ECG signal ID.
+	HeartData    withings.HeartDatas
+	HeartSignals map[int64]withings.HeartHighFrequencyData
+
+	// Data served by the stetho v2 service. StethoSignals is keyed by the signal ID of the recording.
+	StethoRecordings withings.StethoRecordings
+	StethoSignals    map[int64]withings.StethoSignal
+
+	// Data served by the sleep v2 service.
+	Sleeps         withings.Sleeps
+	SleepSummaries withings.SleepSummaries
+
+	// Data served by the user v2 service.
+	Devices withings.Devices
+	Goals   withings.Goals
+
+	// The notification subscriptions of the user managed through the notify service.
+	Notifications withings.Notifications
+}
+
+// NewUser returns a new user with the ID provided and no data.
+func NewUser(userID string) *User {
+	return &User{
+		ID:                 userID,
+		IntraDayActivities: make(withings.IntraDayActivities),
+		HeartSignals:       make(map[int64]withings.HeartHighFrequencyData),
+		StethoSignals:      make(map[int64]withings.StethoSignal),
+	}
+}
+
+// NewDemoUser returns a new user seeded with a week of data of every kind ending at now. It mirrors the data the
+// Withings demo account provides.
+func NewDemoUser(userID string, now time.Time) *User {
+	u := NewUser(userID)
+	deviceID := "demo-device"
+
+	u.Devices = withings.Devices{
+		{
+			Type:            "Scale",
+			Model:           "Body Cardio",
+			ModelID:         6,
+			Battery:         "high",
+			DeviceID:        deviceID,
+			HashDeviceID:    deviceID,
+			Timezone:        "Europe/Paris",
+			LastSessionDate: int(now.Unix()),
+		},
+	}
+
+	for day := 6; day >= 0; day-- {
+		date := now.Add(-time.Duration(day) * 24 * time.Hour)
+		unix := date.Unix()
+
+		u.MeasureGroups = append(u.MeasureGroups, withings.MeasureGroup{
+			GroupID:  int64(1000 + day),
+			Attrib:   0,
+			Date:     unix,
+			Created:  unix,
+			Category: int64(withings.MeasureCategoryReal),
+			DeviceID: deviceID,
+			Measures: withings.Measures{
+				{Value: int64(80000 - day*100), Type: withings.MeasureTypeWeightKilogram, Unit: -3},
+				{Value: 180, Type: withings.MeasureTypeHeightMeter, Unit: -2},
+				{Value: 62, Type: withings.MeasureTypeHeartPulseBPM, Unit: 0},
+			},
+		})
+
+		u.Activities = append(u.Activities, withings.Activity{
+			Date:      date.Format("2006-01-02"),
+			Timezone:  "Europe/Paris",
+			DeviceID:  deviceID,
+			IsTracker: true,
+			Steps:     float64Ptr(float64(8000 + day*250)),
+			Distance:  float64Ptr(float64(6000 + day*200)),
+			Calories:  float64Ptr(float64(350 + day*10)),
+		})
+
+		for minute := 0; minute < 60; minute += 10 {
+			u.IntraDayActivities[unix-int64(minute*60)] = withings.IntraDayActivity{
+				DeviceID:  deviceID,
+				Model:     "Body Cardio",
+				ModelID:   6,
+				Steps:     float64Ptr(float64(100 + minute)),
+				Calories:  float64Ptr(float64(5 + minute/10)),
+				HeartRate: float64Ptr(float64(70 + minute/10)),
+			}
+		}
+
+		u.Workouts = append(u.Workouts, withings.Workout{
+			Category:  1,
+			Timezone:  "Europe/Paris",
+			StartDate: int(unix - 3600),
+			EndDate:   int(unix),
+			Date:      date.Format("2006-01-02"),
+			Modified:  int(unix),
+			DeviceID:  deviceID,
+			Data: withings.WorkoutData{
+				Calories: float64Ptr(float64(250 + day)),
+				Steps:    float64Ptr(float64(4000 + day)),
+			},
+		})
+
+		signalID := int64(5000 + day)
+		u.HeartData = append(u.HeartData, withings.HeartData{
+			DeviceID:  deviceID,
+			Model:     44,
+			HeartRate: int64(60 + day),
+			Timestamp: unix,
+		})
+		u.HeartData[len(u.HeartData)-1].Ecg.SignalID = signalID
+		u.HeartSignals[signalID] = withings.HeartHighFrequencyData{
+			Signal:            "[1,2,3,2,1]",
+			SamplingFrequency: 500,
+			WearPosition:      0,
+		}
+
+		stethoID := int64(6000 + day)
+		u.StethoRecordings = append(u.StethoRecordings, withings.StethoRecording{
+			DeviceID:  deviceID,
+			Model:     44,
+			SignalID:  stethoID,
+			Timestamp: unix,
+		})
+		u.StethoSignals[stethoID] = withings.StethoSignal{
+			Signal:      []int64{0, 120, -120, 60, -60, 0},
+			Frequency:   4000,
+			Duration:    20,
+			Resolution:  16,
+			Channel:     1,
+			DeviceModel: 44,
+		}
+
+		bedtime := date.Add(-8 * time.Hour)
+		u.Sleeps = append(u.Sleeps, withings.Sleep{
+			StartDate: int(bedtime.Unix()),
+			EndDate:   int(unix),
+			State:     1,
+			HR:        map[int64]float64{bedtime.Unix(): 55},
+			RR:        map[int64]float64{bedtime.Unix(): 14},
+		})
+
+		summary := withings.SleepSummary{
+			Timezone:  "Europe/Paris",
+			Model:     32,
+			StartDate: int(bedtime.Unix()),
+			EndDate:   int(unix),
+			Date:      date.Format("2006-01-02"),
+			Created:   int(unix),
+			Modified:  int(unix),
+		}
+		summary.Data.TotalSleepTime = float64Ptr(float64(7 * 3600))
+		summary.Data.SleepScore = float64Ptr(float64(80 + day))
+		u.SleepSummaries = append(u.SleepSummaries, summary)
+	}
+
+	u.Goals = withings.Goals{
+		Steps:  10000,
+		Sleep:  8 * 3600,
+		Weight: &withings.WeightGoal{Value: 75500, Unit: -3},
+	}
+
+	return u
+}
+
+// float64Ptr returns a pointer to v.
+func float64Ptr(v float64) *float64 {
+	return &v
+}